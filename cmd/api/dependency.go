@@ -0,0 +1,25 @@
+package main
+
+import (
+	"github.com/wisesight/go-api-template/cmd/api/handler"
+	"github.com/wisesight/go-api-template/cmd/api/route"
+	"github.com/wisesight/go-api-template/cmd/internal/dependency"
+	"github.com/wisesight/go-api-template/config"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/log"
+)
+
+// newHandlers builds the handlers of the API from the usecases, so a handler cannot be registered without its
+// usecase.
+func newHandlers(cfg config.Config, mongoDBAdapter adapter.IMongoDBAdapter, usecases dependency.Usecases, logger log.ILogger) route.Handlers {
+	return route.Handlers{
+		User:  handler.NewUser(usecases.User, logger),
+		Probe: handler.NewProbe(mongoDBAdapter, logger),
+		Job:   handler.NewJob(usecases.Job, logger),
+		Auth: handler.NewAuth(handler.AuthConfig{
+			JWTSecret:        cfg.JWTSecret,
+			JWTSigningMethod: cfg.JWTSigningMethod,
+			TokenExpiration:  cfg.JWTExpiration,
+		}, usecases.Credential, logger),
+	}
+}
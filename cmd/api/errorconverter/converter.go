@@ -1,28 +1,160 @@
 package errorconverter
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
+	gpgvalidator "github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 
 	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/validator"
 )
 
-func ResponseError(c echo.Context, err error) error {
-	type ErrorResponse struct {
-		Message string
+// MIMEProblemJSON is the media type of RFC 7807 problem details.
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object, extended with the apperror code, the request ID and, for invalid
+// requests, the invalid fields.
+type Problem struct {
+	Type      string       `json:"type" example:"about:blank"`
+	Title     string       `json:"title" example:"Not Found"`
+	Status    int          `json:"status" example:"404"`
+	Detail    string       `json:"detail,omitempty" example:"User not found"`
+	Instance  string       `json:"instance,omitempty" example:"/user/63dccac268616ec85ccfcfd2"`
+	Code      string       `json:"code,omitempty" example:"NOT_FOUND"`
+	RequestID string       `json:"request_id,omitempty" example:"0b6c3c4e-8f7a-4f43-9d0c-3f0d8a7f8a4e"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is one invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field" example:"username"`
+	Message string `json:"message" example:"username is a required field"`
+}
+
+// StatusRegistry maps apperror codes to HTTP statuses. Codes it does not list are 500.
+type StatusRegistry map[apperror.AppErrorCode]int
+
+// DefaultStatusRegistry returns the statuses of every apperror code. Register new codes here, or on the copy it
+// returns for statuses specific to one application.
+func DefaultStatusRegistry() StatusRegistry {
+	return StatusRegistry{
+		apperror.NotFound:             http.StatusNotFound,
+		apperror.InvalidArgument:      http.StatusBadRequest,
+		apperror.InvalidID:            http.StatusBadRequest,
+		apperror.DuplicateKey:         http.StatusConflict,
+		apperror.VersionConflict:      http.StatusConflict,
+		apperror.Timeout:              http.StatusGatewayTimeout,
+		apperror.Unauthenticated:      http.StatusUnauthorized,
+		apperror.MySQLSyntaxError:     http.StatusInternalServerError,
+		apperror.PreconditionRequired: http.StatusPreconditionRequired,
+		apperror.PreconditionFailed:   http.StatusPreconditionFailed,
+		apperror.UnsupportedFormat:    http.StatusUnsupportedMediaType,
+		apperror.Unprocessable:        http.StatusUnprocessableEntity,
+	}
+}
+
+// Status returns the HTTP status of code.
+func (r StatusRegistry) Status(code apperror.AppErrorCode) int {
+	if status, ok := r[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+type HTTPErrorHandlerConfig struct {
+	// Statuses defaults to DefaultStatusRegistry.
+	Statuses StatusRegistry
+}
+
+// NewHTTPErrorHandler renders every error a handler returns as application/problem+json:
+//   - *echo.HTTPError with its own status and message,
+//   - apperror.AppError with the status of its code and its message as detail,
+//   - validator.ValidationErrors as 400 with the invalid fields in errors,
+//   - anything else as 500, without revealing the error, which is logged instead.
+func NewHTTPErrorHandler(httpErrorHandlerConfig HTTPErrorHandlerConfig, logger log.ILogger) echo.HTTPErrorHandler {
+	if httpErrorHandlerConfig.Statuses == nil {
+		httpErrorHandlerConfig.Statuses = DefaultStatusRegistry()
+	}
+	statuses := httpErrorHandlerConfig.Statuses
+
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		problem := newProblem(err, statuses)
+		problem.Instance = c.Request().URL.Path
+		problem.RequestID, _ = c.Request().Context().Value(log.RequestIDKey).(string)
+
+		if problem.Status >= http.StatusInternalServerError {
+			logger.Error(c.Request().Context(), "request failed", log.Int("status", problem.Status), log.Error(err))
+		}
+
+		var respErr error
+		if c.Request().Method == http.MethodHead {
+			respErr = c.NoContent(problem.Status)
+		} else {
+			c.Response().Header().Set(echo.HeaderContentType, MIMEProblemJSON)
+			respErr = c.JSON(problem.Status, problem)
+		}
+		if respErr != nil {
+			logger.Error(c.Request().Context(), "write error response failed", log.Error(respErr))
+		}
+	}
+}
+
+func newProblem(err error, statuses StatusRegistry) Problem {
+	var (
+		appErr         apperror.AppError
+		validationErrs gpgvalidator.ValidationErrors
+		httpErr        *echo.HTTPError
+	)
+
+	switch {
+	case errors.As(err, &httpErr):
+		// Checked first, as a handler may return an HTTPError with an AppError as the internal error to pick another
+		// status, such as 412 for a version conflict on a conditional request.
+		problem := statusProblem(httpErr.Code)
+		if message, ok := httpErr.Message.(string); ok {
+			problem.Detail = message
+		} else if httpErr.Message != nil {
+			problem.Detail = fmt.Sprint(httpErr.Message)
+		}
+		if errors.As(httpErr.Internal, &appErr) {
+			problem.Code = string(appErr.Code)
+		}
+		return problem
+	case errors.As(err, &appErr):
+		problem := statusProblem(statuses.Status(appErr.Code))
+		problem.Detail = appErr.Message
+		problem.Code = string(appErr.Code)
+		return problem
+	case errors.As(err, &validationErrs):
+		problem := statusProblem(http.StatusBadRequest)
+		problem.Detail = "The request is invalid"
+		problem.Code = string(apperror.InvalidArgument)
+		for _, fieldErr := range validationErrs {
+			problem.Errors = append(problem.Errors, FieldError{
+				Field:   fieldErr.Field(),
+				Message: fieldErr.Translate(validator.Trans),
+			})
+		}
+		return problem
+	default:
+		problem := statusProblem(http.StatusInternalServerError)
+		problem.Detail = "Internal server error"
+		return problem
 	}
+}
 
-	switch e := err.(type) {
-	case apperror.AppError:
-		// Code แต่ละ handler จะมีความหมายไม่เหมือนกัน อาจจะใช้ coverter เดียวกันไม่ได้
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Message: e.Message,
-		})
-	case error:
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Message: "Internal server error",
-		})
+func statusProblem(status int) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
 	}
-	return nil
 }
@@ -0,0 +1,162 @@
+package errorconverter_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/cmd/api/errorconverter"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/validator"
+)
+
+type ConverterSuite struct {
+	suite.Suite
+	handler echo.HTTPErrorHandler
+}
+
+func (s *ConverterSuite) SetupSuite() {
+	s.Require().NoError(validator.NewValidator())
+
+	logger, err := log.NewLoggerZap(&log.ZapConfig{})
+	s.Require().NoError(err)
+
+	s.handler = errorconverter.NewHTTPErrorHandler(errorconverter.HTTPErrorHandlerConfig{}, logger)
+}
+
+func TestConverterSuite(t *testing.T) {
+	suite.Run(t, new(ConverterSuite))
+}
+
+// handle renders err for a request and returns the response and the decoded problem.
+func (s *ConverterSuite) handle(req *http.Request, err error) (*httptest.ResponseRecorder, errorconverter.Problem) {
+	rec := httptest.NewRecorder()
+	s.handler(err, echo.New().NewContext(req, rec))
+
+	var problem errorconverter.Problem
+	if rec.Body.Len() > 0 {
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &problem))
+	}
+	return rec, problem
+}
+
+func (s *ConverterSuite) TestStatusRegistry() {
+	registry := errorconverter.DefaultStatusRegistry()
+
+	for code, status := range map[apperror.AppErrorCode]int{
+		apperror.NotFound:             http.StatusNotFound,
+		apperror.InvalidArgument:      http.StatusBadRequest,
+		apperror.DuplicateKey:         http.StatusConflict,
+		apperror.Timeout:              http.StatusGatewayTimeout,
+		apperror.Unauthenticated:      http.StatusUnauthorized,
+		apperror.PreconditionFailed:   http.StatusPreconditionFailed,
+		apperror.PreconditionRequired: http.StatusPreconditionRequired,
+		apperror.UnsupportedFormat:    http.StatusUnsupportedMediaType,
+		apperror.Unprocessable:        http.StatusUnprocessableEntity,
+	} {
+		s.Equal(status, registry.Status(code), code)
+	}
+
+	s.Run("should map unknown codes to 500", func() {
+		s.Equal(http.StatusInternalServerError, registry.Status("UNKNOWN"))
+	})
+}
+
+func (s *ConverterSuite) TestNewHTTPErrorHandler() {
+
+	s.Run("should render an app error with the status of its code", func() {
+		rec, problem := s.handle(httptest.NewRequest(http.MethodGet, "/user/1", nil),
+			apperror.NewError("User not found", "no user 1", apperror.NotFound))
+
+		s.Equal(http.StatusNotFound, rec.Code)
+		s.Equal(errorconverter.MIMEProblemJSON, rec.Header().Get(echo.HeaderContentType))
+		s.Equal(errorconverter.Problem{
+			Type:     "about:blank",
+			Title:    "Not Found",
+			Status:   http.StatusNotFound,
+			Detail:   "User not found",
+			Instance: "/user/1",
+			Code:     string(apperror.NotFound),
+		}, problem)
+	})
+
+	s.Run("should use the statuses of the config", func() {
+		logger, _ := log.NewLoggerZap(&log.ZapConfig{})
+		handler := errorconverter.NewHTTPErrorHandler(errorconverter.HTTPErrorHandlerConfig{
+			Statuses: errorconverter.StatusRegistry{apperror.DuplicateKey: http.StatusUnprocessableEntity},
+		}, logger)
+		rec := httptest.NewRecorder()
+
+		handler(apperror.NewError("User already exists", "", apperror.DuplicateKey),
+			echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/user", nil), rec))
+
+		s.Equal(http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	s.Run("should list the invalid fields of validation errors", func() {
+		body := struct {
+			Username string `json:"username" validate:"required"`
+			Name     string `json:"name" validate:"max=3"`
+		}{Name: "long"}
+
+		rec, problem := s.handle(httptest.NewRequest(http.MethodPost, "/user", nil), validator.Validate.Struct(body))
+
+		s.Equal(http.StatusBadRequest, rec.Code)
+		s.Equal(string(apperror.InvalidArgument), problem.Code)
+		s.ElementsMatch([]errorconverter.FieldError{
+			{Field: "username", Message: "username is a required field"},
+			{Field: "name", Message: "name must be a maximum of 3 characters in length"},
+		}, problem.Errors)
+	})
+
+	s.Run("should include the request ID", func() {
+		req := httptest.NewRequest(http.MethodGet, "/user/1", nil)
+		req = req.WithContext(context.WithValue(req.Context(), log.RequestIDKey, "request-id"))
+
+		_, problem := s.handle(req, apperror.NewError("User not found", "", apperror.NotFound))
+
+		s.Equal("request-id", problem.RequestID)
+	})
+
+	s.Run("should render echo errors with their status", func() {
+		rec, problem := s.handle(httptest.NewRequest(http.MethodGet, "/nowhere", nil), echo.ErrNotFound)
+
+		s.Equal(http.StatusNotFound, rec.Code)
+		s.Equal("Not Found", problem.Detail)
+		s.Empty(problem.Code)
+	})
+
+	s.Run("should hide unknown errors behind a 500", func() {
+		rec, problem := s.handle(httptest.NewRequest(http.MethodGet, "/user", nil),
+			errors.New("dial tcp 10.0.0.1:27017: connection refused"))
+
+		s.Equal(http.StatusInternalServerError, rec.Code)
+		s.Equal("Internal server error", problem.Detail)
+		s.NotContains(rec.Body.String(), "10.0.0.1")
+	})
+
+	s.Run("should send no body to HEAD requests", func() {
+		rec, _ := s.handle(httptest.NewRequest(http.MethodHead, "/user/1", nil),
+			apperror.NewError("User not found", "", apperror.NotFound))
+
+		s.Equal(http.StatusNotFound, rec.Code)
+		s.Zero(rec.Body.Len())
+	})
+
+	s.Run("should leave committed responses alone", func() {
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/user:export", nil), rec)
+		s.Require().NoError(c.String(http.StatusOK, "partial"))
+
+		s.handler(errors.New("stream failed"), c)
+
+		s.Equal(http.StatusOK, rec.Code)
+		s.Equal("partial", rec.Body.String())
+	})
+}
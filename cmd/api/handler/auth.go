@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/usecase"
+	"github.com/wisesight/go-api-template/pkg/validator"
+)
+
+type IAuth interface {
+	Login(c echo.Context) error
+}
+
+type AuthConfig struct {
+	JWTSecret        string
+	JWTSigningMethod string
+	// TokenExpiration defaults to an hour.
+	TokenExpiration time.Duration
+}
+
+type auth struct {
+	credentialUseCase usecase.ICredential
+	secret            []byte
+	signingMethod     jwt.SigningMethod
+	tokenExpiration   time.Duration
+	logger            log.ILogger
+}
+
+func NewAuth(authConfig AuthConfig, credentialUseCase usecase.ICredential, logger log.ILogger) IAuth {
+	if authConfig.TokenExpiration <= 0 {
+		authConfig.TokenExpiration = time.Hour
+	}
+	return &auth{
+		credentialUseCase: credentialUseCase,
+		secret:            []byte(authConfig.JWTSecret),
+		signingMethod:     jwt.GetSigningMethod(authConfig.JWTSigningMethod),
+		tokenExpiration:   authConfig.TokenExpiration,
+		logger:            logger,
+	}
+}
+
+type LoginRequestBody struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginResponseBody struct {
+	AccessToken string `json:"access_token" example:"eyJhbGciOiJIUzI1NiJ9..."`
+	TokenType   string `json:"token_type" example:"Bearer"`
+	ExpiresIn   int64  `json:"expires_in" example:"3600"`
+}
+
+// Login godoc
+// @id           login
+// @summary      Log in
+// @description  Exchange a username and password for an access token to send as a Bearer token
+// @tags         auth
+// @accept       json
+// @produce      json
+// @param        data  body  LoginRequestBody  true  "Credentials"
+// @success      200  {object}  LoginResponseBody
+// @failure      400  {object}  errorconverter.Problem
+// @failure      401  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /auth/login [post]
+func (h auth) Login(c echo.Context) error {
+	body := &LoginRequestBody{}
+	if err := c.Bind(body); err != nil {
+		return bindError(err)
+	}
+	if err := validator.Validate.Struct(body); err != nil {
+		return err
+	}
+
+	user, err := h.credentialUseCase.Authenticate(c.Request().Context(), body.Username, body.Password)
+	if err != nil {
+		var appErr apperror.AppError
+		if errors.As(err, &appErr) && appErr.Code == apperror.Unauthenticated {
+			h.logger.Info(c.Request().Context(), "login failed", log.String("username", body.Username))
+		}
+		return err
+	}
+
+	if h.signingMethod == nil {
+		return errors.New("JWT signing method is not configured")
+	}
+
+	now := time.Now()
+	token, err := jwt.NewWithClaims(h.signingMethod, jwt.MapClaims{
+		"sub":      user.ID,
+		"user_id":  user.ID,
+		"username": user.Username,
+		"iat":      now.Unix(),
+		"exp":      now.Add(h.tokenExpiration).Unix(),
+	}).SignedString(h.secret)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &LoginResponseBody{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(h.tokenExpiration.Seconds()),
+	})
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/helper"
+	"github.com/wisesight/go-api-template/pkg/patch"
+)
+
+// bindError reports a request that echo cannot bind as an invalid argument.
+func bindError(err error) error {
+	message := helper.EchoBindErrorTranslator(err)
+	return apperror.NewError(message, err.Error(), apperror.InvalidArgument)
+}
+
+func invalidArgument(message string) error {
+	return apperror.NewError(message, message, apperror.InvalidArgument)
+}
+
+func unsupportedFormat(message string) error {
+	return apperror.NewError(message, message, apperror.UnsupportedFormat)
+}
+
+func unprocessable(message string) error {
+	return apperror.NewError(message, message, apperror.Unprocessable)
+}
+
+// patchError maps the errors of package patch to apperror codes.
+func patchError(err error) error {
+	if errors.Is(err, patch.ErrUnprocessable) {
+		return unprocessable(err.Error())
+	}
+	return invalidArgument(err.Error())
+}
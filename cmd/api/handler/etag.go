@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/wisesight/go-api-template/pkg/apperror"
+)
+
+// etag formats a version as a strong entity tag.
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatch is a parsed If-Match header.
+type ifMatch struct {
+	any      bool
+	versions []int64
+}
+
+// parseIfMatch parses an If-Match header, either * or a comma-separated list of entity tags. If-Match compares
+// tags strongly (RFC 7232 section 3.1), so weak tags never match and a list of only weak tags fails. Strong tags
+// not made by etag are kept out of versions, as they match no version.
+func parseIfMatch(header string) (ifMatch, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return ifMatch{}, ifMatchRequired()
+	}
+	if header == "*" {
+		return ifMatch{any: true}, nil
+	}
+
+	var (
+		match ifMatch
+		weak  int
+		tags  int
+	)
+	for rest := header; rest != ""; {
+		rest = strings.TrimLeft(rest, " \t")
+		isWeak := strings.HasPrefix(rest, "W/")
+		rest = strings.TrimPrefix(rest, "W/")
+
+		if !strings.HasPrefix(rest, `"`) {
+			return ifMatch{}, ifMatchInvalid()
+		}
+		end := strings.IndexByte(rest[1:], '"')
+		if end < 0 {
+			return ifMatch{}, ifMatchInvalid()
+		}
+		opaque := rest[1 : end+1]
+		rest = strings.TrimLeft(rest[end+2:], " \t")
+		if rest != "" {
+			if rest[0] != ',' {
+				return ifMatch{}, ifMatchInvalid()
+			}
+			rest = strings.TrimLeft(rest[1:], " \t")
+			if rest == "" {
+				return ifMatch{}, ifMatchInvalid()
+			}
+		}
+
+		tags++
+		if isWeak {
+			weak++
+			continue
+		}
+		if version, err := strconv.ParseInt(opaque, 10, 64); err == nil && version >= 0 {
+			match.versions = append(match.versions, version)
+		}
+	}
+
+	if weak == tags {
+		return ifMatch{}, apperror.NewError("If-Match has only weak ETags",
+			"If-Match compares ETags strongly, so weak ETags never match. Send the ETag of the user as it was read.",
+			apperror.PreconditionFailed)
+	}
+	return match, nil
+}
+
+// matches reports whether the header allows a change to the given version.
+func (m ifMatch) matches(version int64) bool {
+	if m.any {
+		return true
+	}
+	for _, v := range m.versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func ifMatchRequired() error {
+	return apperror.NewError("If-Match header is required", "If-Match header is required", apperror.PreconditionRequired)
+}
+
+// ifMatchInvalid reports a malformed If-Match as a bad request: nothing was compared, so no precondition failed.
+func ifMatchInvalid() error {
+	return apperror.NewError("If-Match is not a list of ETags",
+		`If-Match must be * or a comma-separated list of ETags, such as "3"`, apperror.InvalidArgument)
+}
+
+func ifMatchFailed() error {
+	return apperror.NewError("If-Match does not match the user", "If-Match does not match the user", apperror.PreconditionFailed)
+}
+
+// conditionalError reports a version conflict of a request made with If-Match as a failed precondition.
+func conditionalError(err error) error {
+	var appErr apperror.AppError
+	if errors.As(err, &appErr) && appErr.Code == apperror.VersionConflict {
+		return apperror.NewError(appErr.Message, appErr.Description, apperror.PreconditionFailed)
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+)
+
+type ETagSuite struct {
+	suite.Suite
+}
+
+func TestETagSuite(t *testing.T) {
+	suite.Run(t, new(ETagSuite))
+}
+
+func (s *ETagSuite) code(err error) apperror.AppErrorCode {
+	var appErr apperror.AppError
+	s.Require().ErrorAs(err, &appErr)
+	return appErr.Code
+}
+
+func (s *ETagSuite) TestParseIfMatch() {
+	tests := []struct {
+		name   string
+		header string
+		want   ifMatch
+	}{
+		{"should read the version of an ETag", `"3"`, ifMatch{versions: []int64{3}}},
+		{"should read the ETag made by etag", etag(42), ifMatch{versions: []int64{42}}},
+		{"should match any version with *", " * ", ifMatch{any: true}},
+		{"should read every ETag of a list", `"1", "2" ,"3"`, ifMatch{versions: []int64{1, 2, 3}}},
+		{"should skip the weak ETags of a list", `W/"1", "2"`, ifMatch{versions: []int64{2}}},
+		{"should skip ETags that are not versions", `"abc", "-1", "2"`, ifMatch{versions: []int64{2}}},
+		{"should keep commas inside an ETag", `"1,2"`, ifMatch{}},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			match, err := parseIfMatch(test.header)
+
+			s.NoError(err)
+			s.Equal(test.want, match)
+		})
+	}
+
+	s.Run("should require a header", func() {
+		_, err := parseIfMatch(" ")
+
+		s.Equal(apperror.PreconditionRequired, s.code(err))
+	})
+
+	s.Run("should fail a header of only weak ETags", func() {
+		_, err := parseIfMatch(`W/"1", W/"2"`)
+
+		s.Equal(apperror.PreconditionFailed, s.code(err))
+		s.Contains(err.Error(), "weak")
+	})
+
+	for _, header := range []string{`3`, `"3`, `"1" "2"`, `"1",`, `,"1"`, `W/3`, `*, "1"`} {
+		s.Run("should reject the malformed header "+header+" as an invalid argument", func() {
+			_, err := parseIfMatch(header)
+
+			s.Equal(apperror.InvalidArgument, s.code(err))
+		})
+	}
+}
+
+func (s *ETagSuite) TestMatches() {
+	s.True(ifMatch{any: true}.matches(7))
+	s.True(ifMatch{versions: []int64{1, 7}}.matches(7))
+	s.False(ifMatch{versions: []int64{1, 2}}.matches(7))
+	s.False(ifMatch{}.matches(0))
+}
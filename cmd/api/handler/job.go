@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/repository"
+	"github.com/wisesight/go-api-template/pkg/usecase"
+	"github.com/wisesight/go-api-template/pkg/validator"
+)
+
+type IJob interface {
+	List(c echo.Context) error
+	Get(c echo.Context) error
+	Retry(c echo.Context) error
+	PurgeCompleted(c echo.Context) error
+}
+
+type job struct {
+	jobUseCase usecase.IJob
+	logger     log.ILogger
+}
+
+func NewJob(jobUseCase usecase.IJob, logger log.ILogger) IJob {
+	return &job{
+		jobUseCase: jobUseCase,
+		logger:     logger,
+	}
+}
+
+type ListJobsRequestQuery struct {
+	State string `query:"state" json:"state" validate:"omitempty,oneof=pending running completed dead"`
+	Type  string `query:"type" json:"type"`
+	Page  int64  `query:"page" json:"page" validate:"min=1"`
+	Limit int64  `query:"limit" json:"limit" validate:"min=1,max=100"`
+}
+
+type ListJobsResponseBody struct {
+	Data  []entity.Job `json:"data"`
+	Page  int64        `json:"page" example:"1"`
+	Limit int64        `json:"limit" example:"20"`
+	Total int64        `json:"total" example:"42"`
+}
+
+// List godoc
+// @id           list-jobs
+// @summary      List jobs
+// @description  List worker jobs by state and type, newest first
+// @tags         admin
+// @produce      json
+// @param        state  query  string  false  "Job state"  Enums(pending, running, completed, dead)
+// @param        type   query  string  false  "Job type"
+// @param        page   query  int     false  "Page number"  default(1)
+// @param        limit  query  int     false  "Page size"    default(20)
+// @success      200  {object}  ListJobsResponseBody
+// @failure      400  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /admin/jobs [get]
+func (h job) List(c echo.Context) error {
+	query := &ListJobsRequestQuery{Page: 1, Limit: 20}
+	if err := c.Bind(query); err != nil {
+		return bindError(err)
+	}
+	if err := validator.Validate.Struct(query); err != nil {
+		return err
+	}
+
+	filter := repository.JobFilter{
+		State: entity.JobState(query.State),
+		Type:  query.Type,
+	}
+	jobs, total, err := h.jobUseCase.List(c.Request().Context(), filter, query.Page, query.Limit)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &ListJobsResponseBody{
+		Data:  jobs,
+		Page:  query.Page,
+		Limit: query.Limit,
+		Total: total,
+	})
+}
+
+// Get godoc
+// @id           get-job
+// @summary      Show a job
+// @description  Show a job, including the error of every failed attempt
+// @tags         admin
+// @produce      json
+// @param        id  path  string  true  "Job ID"
+// @success      200  {object}  entity.Job
+// @failure      400  {object}  errorconverter.Problem
+// @failure      404  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /admin/jobs/{id} [get]
+func (h job) Get(c echo.Context) error {
+	id := c.Param("id")
+	job, err := h.jobUseCase.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+// Retry godoc
+// @id           retry-job
+// @summary      Retry a dead job
+// @description  Move a dead-lettered job back to the queue with fresh attempts
+// @tags         admin
+// @produce      json
+// @param        id  path  string  true  "Job ID"
+// @success      200  {object}  entity.Job
+// @failure      400  {object}  errorconverter.Problem
+// @failure      404  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /admin/jobs/{id}/retry [post]
+func (h job) Retry(c echo.Context) error {
+	id := c.Param("id")
+	job, err := h.jobUseCase.Requeue(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info(c.Request().Context(), "dead job requeued", log.String("jobID", id))
+
+	return c.JSON(http.StatusOK, job)
+}
+
+type PurgeCompletedJobsRequestQuery struct {
+	Before time.Time `query:"before" json:"before" validate:"required"`
+}
+
+type PurgeCompletedJobsResponseBody struct {
+	Deleted int64 `json:"deleted" example:"42"`
+}
+
+// PurgeCompleted godoc
+// @id           purge-completed-jobs
+// @summary      Purge completed jobs
+// @description  Delete completed jobs that finished before the cutoff
+// @tags         admin
+// @produce      json
+// @param        before  query  string  true  "RFC 3339 cutoff"
+// @success      200  {object}  PurgeCompletedJobsResponseBody
+// @failure      400  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /admin/jobs/completed [delete]
+func (h job) PurgeCompleted(c echo.Context) error {
+	query := &PurgeCompletedJobsRequestQuery{}
+	if err := c.Bind(query); err != nil {
+		return bindError(err)
+	}
+	if err := validator.Validate.Struct(query); err != nil {
+		return err
+	}
+
+	deleted, err := h.jobUseCase.PurgeCompleted(c.Request().Context(), query.Before)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info(c.Request().Context(), "completed jobs purged",
+		log.String("before", query.Before.Format(time.RFC3339)),
+		log.Int64("deleted", deleted),
+	)
+
+	return c.JSON(http.StatusOK, &PurgeCompletedJobsResponseBody{
+		Deleted: deleted,
+	})
+}
@@ -1,16 +1,21 @@
 package handler
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"time"
 
-	gpgvalidator "github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/wisesight/go-api-template/constant"
+	"github.com/wisesight/go-api-template/pkg/adapter"
 	"github.com/wisesight/go-api-template/pkg/entity"
-	"github.com/wisesight/go-api-template/pkg/helper"
 	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/patch"
+	"github.com/wisesight/go-api-template/pkg/query"
 	"github.com/wisesight/go-api-template/pkg/usecase"
 	"github.com/wisesight/go-api-template/pkg/validator"
 )
@@ -18,7 +23,15 @@ import (
 type IUser interface {
 	GetAll(c echo.Context) error
 	GetUser(c echo.Context) error
+	Get(c echo.Context) error
 	Create(c echo.Context) error
+	Import(c echo.Context) error
+	Export(c echo.Context) error
+	Update(c echo.Context) error
+	Patch(c echo.Context) error
+	Delete(c echo.Context) error
+	Restore(c echo.Context) error
+	GetAudit(c echo.Context) error
 }
 
 type user struct {
@@ -26,84 +39,513 @@ type user struct {
 	logger      log.ILogger
 }
 
-func NewUser(logger log.ILogger) IUser {
+func NewUser(userUseCase usecase.IUser, logger log.ILogger) IUser {
 	newUserValidation()
 	return &user{
-		logger: logger,
+		userUseCase: userUseCase,
+		logger:      logger,
 	}
 }
 
+// userQuerySchema is what GET /user may filter and sort by.
+var userQuerySchema = query.Schema{
+	Fields: map[string]query.Field{
+		"name": {
+			Path:      "name",
+			Operators: []query.Operator{query.Equal, query.Prefix},
+			Sortable:  true,
+		},
+		"username": {
+			Path:      "username",
+			Operators: []query.Operator{query.Equal, query.In},
+			Sortable:  true,
+		},
+		"birth_date": {
+			Path:      "birth_date",
+			Type:      query.Time,
+			Operators: []query.Operator{query.Equal, query.GreaterThan, query.GreaterOrEqual, query.LessThan, query.LessOrEqual},
+			Sortable:  true,
+		},
+	},
+	TextFields: []string{"name", "username"},
+}
+
+// userPagingParams are the GET /user parameters that are not filters.
+var userPagingParams = []string{"limit", "offset", "cursor", "sort"}
+
+type GetAllRequestQuery struct {
+	Limit  int64  `query:"limit" json:"limit" validate:"min=1,max=100"`
+	Offset int64  `query:"offset" json:"offset" validate:"min=0"`
+	Cursor string `query:"cursor" json:"cursor"`
+	Sort   string `query:"sort" json:"sort"`
+}
+
+type UserResponseBody struct {
+	ID        string    `json:"id" example:"63dccac268616ec85ccfcfd2"`
+	Name      string    `json:"name" example:"John Doe"`
+	Username  string    `json:"username" example:"johndoe"`
+	BirthDate time.Time `json:"birth_date" example:"2006-01-02"`
+}
+
+type PagingResponseBody struct {
+	Limit      int64  `json:"limit" example:"20"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJzIjpbXX0"`
+	HasMore    bool   `json:"has_more" example:"true"`
+}
+
+type GetAllResponseBody struct {
+	Data   []UserResponseBody `json:"data"`
+	Paging PagingResponseBody `json:"paging"`
+}
+
 // GetAll godoc
 // @id           get-all-users
 // @summary      Show all users
-// @description  Show all active users in the system
+// @description  Show a page of the users matching every filter. Filters are field[op]=value, or field=value for eq.
+// @description  Pass paging.next_cursor back as cursor, with the same filters and sort, to get the next page
 // @tags         users
 // @accept       json
 // @produce      json
-// @success      200  {array}   CreateResponseBody
-// @failure      400  {object}  echo.HTTPError
-// @failure      404  {object}  echo.HTTPError
-// @failure      500  {object}  echo.HTTPError
-// @router       /users [get]
+// @param        limit   query  int     false  "Page size"  default(20)
+// @param        offset  query  int     false  "Users to skip, ignored with cursor"
+// @param        cursor  query  string  false  "Cursor from the previous page"
+// @param        sort    query  string  false  "Comma-separated name, username or birth_date; prefix with - to sort descending"
+// @param        q       query  string  false  "Free text to find in the name or username"
+// @param        name[prefix]     query  string  false  "Name prefix; name[eq] also works"
+// @param        username         query  string  false  "Username; username[in] takes a comma-separated list"
+// @param        birth_date[gte]  query  string  false  "Earliest birth date; also [eq], [gt], [lt] and [lte]"
+// @success      200  {object}  GetAllResponseBody
+// @failure      400  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /user [get]
 func (h user) GetAll(c echo.Context) error {
-	users, err := h.userUseCase.GetAll()
-	ctx := c.Request().Context()
+	params := &GetAllRequestQuery{Limit: 20}
+	if err := c.Bind(params); err != nil {
+		return bindError(err)
+	}
+	if err := validator.Validate.Struct(params); err != nil {
+		return err
+	}
 
-	h.logger.Info(ctx, "get all users", log.Any("users", users))
+	filter, err := query.Parse(userQuerySchema, c.QueryParams(), userPagingParams...)
+	if err != nil {
+		return err
+	}
 
+	sort, err := query.ParseSort(userQuerySchema, params.Sort)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	return c.JSON(http.StatusOK, users)
+	users, pageInfo, err := h.userUseCase.GetAll(c.Request().Context(), filter, adapter.Pagination{
+		Limit:  params.Limit,
+		Offset: params.Offset,
+		Cursor: params.Cursor,
+		Sort:   sort,
+	})
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info(c.Request().Context(), "get all users", log.Int("count", len(users)))
+
+	data := make([]UserResponseBody, len(users))
+	for i, user := range users {
+		data[i] = newUserResponseBody(user)
+	}
+
+	return c.JSON(http.StatusOK, &GetAllResponseBody{
+		Data: data,
+		Paging: PagingResponseBody{
+			Limit:      params.Limit,
+			NextCursor: pageInfo.NextCursor,
+			HasMore:    pageInfo.HasMore,
+		},
+	})
 }
 
+// GetUser godoc
+// @id           get-current-user
+// @summary      Show the session
+// @description  Show the user session carried by the JWT
+// @tags         users
+// @produce      json
+// @success      200  {object}  entity.UserSession
+// @router       /user/ [get]
+// @router       /user/me [get]
 func (h user) GetUser(c echo.Context) error {
 	user := c.Get(constant.JWT_CONTEXT_KEY).(entity.UserSession)
 
-	fmt.Println(user)
-
 	return c.JSON(http.StatusOK, user)
 }
 
-type CreateRequestBody struct {
-	Name      string    `json:"name" validate:"required"`
-	Username  string    `json:"username" validate:"required"`
-	Password  string    `json:"password" validate:"is_valid_password,required"`
-	BirthDate time.Time `json:"birth_date" validate:"required"`
+// Get godoc
+// @id           get-user
+// @summary      Show a user
+// @description  Show a user. The ETag header carries the user's version, to send back in If-Match when updating
+// @tags         users
+// @produce      json
+// @param        id  path  string  true  "User ID"
+// @success      200  {object}  UserResponseBody
+// @header       200  {string}  ETag  "User version"
+// @failure      400  {object}  errorconverter.Problem
+// @failure      404  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /user/{id} [get]
+func (h user) Get(c echo.Context) error {
+	user, err := h.userUseCase.GetByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(constant.HEADER_ETAG, etag(user.Version))
+
+	return c.JSON(http.StatusOK, newUserResponseBody(user))
 }
 
-type CreateResponseBody struct {
-	Name      string    `json:"name" example:"John Doe"`
-	Username  string    `json:"username" example:"johndoe"`
-	BirthDate time.Time `json:"birth_date" example:"2006-01-02"`
+type CreateRequestBody struct {
+	Name     string `json:"name" validate:"required"`
+	Username string `json:"username" validate:"required"`
+	// bcrypt ignores everything after 72 bytes.
+	Password  string    `json:"password" validate:"is_valid_password,required,max_bytes=72"`
+	BirthDate time.Time `json:"birth_date" validate:"required"`
 }
 
 // Create godoc
 // @id           create-user
 // @summary      Create a user
-// @description  Create a user in the system
+// @description  Create a user in the system. The password is stored hashed and never returned
 // @tags         users
 // @accept       json
 // @produce      json
-// @param  data  body  entity.User  true  "User data"
-// @success      200  {object}  CreateResponseBody  "Return user data"
-// @failure      400  {object}  echo.HTTPError
-// @failure      404  {object}  echo.HTTPError
-// @failure      500  {object}  echo.HTTPError
-// @router       /users [post]
+// @param  data  body  CreateRequestBody  true  "User data"
+// @success      201  {object}  UserResponseBody  "Return user data"
+// @header       201  {string}  ETag  "User version"
+// @failure      400  {object}  errorconverter.Problem
+// @failure      409  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /user/ [post]
+// @router       /user [post]
 func (h user) Create(c echo.Context) error {
 	body := &CreateRequestBody{}
 	if err := c.Bind(body); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, helper.EchoBindErrorTranslator(err))
+		return bindError(err)
 	}
 	if err := validator.Validate.Struct(body); err != nil {
-		errs := err.(gpgvalidator.ValidationErrors)
-		return echo.NewHTTPError(http.StatusBadRequest, errs.Translate(validator.Trans))
+		return err
 	}
-	return c.JSON(http.StatusCreated, &CreateResponseBody{
+
+	user := entity.User{
 		Name:      body.Name,
-		Username:  body.Password,
+		Username:  body.Username,
+		Password:  body.Password,
 		BirthDate: body.BirthDate,
+	}
+
+	id, err := h.userUseCase.Create(c.Request().Context(), &user)
+	if err != nil {
+		return err
+	}
+	user.ID = id
+
+	c.Response().Header().Set(constant.HEADER_ETAG, etag(user.Version))
+
+	return c.JSON(http.StatusCreated, newUserResponseBody(user))
+}
+
+type UpdateRequestBody struct {
+	Name      string    `json:"name" validate:"required"`
+	Username  string    `json:"username" validate:"required"`
+	BirthDate time.Time `json:"birth_date" validate:"required"`
+}
+
+// Update godoc
+// @id           update-user
+// @summary      Update a user
+// @description  Replace a user's profile. If-Match must carry the ETag from the last read, a list of ETags, or * to update whatever
+// @description  version is stored. The password is not changed.
+// @tags         users
+// @accept       json
+// @produce      json
+// @param        id        path    string             true  "User ID"
+// @param        If-Match  header  string             true  "ETag of the user being updated"
+// @param        data      body    UpdateRequestBody  true  "User data"
+// @success      200  {object}  UserResponseBody
+// @header       200  {string}  ETag  "New user version"
+// @failure      400  {object}  errorconverter.Problem  "The request or its If-Match header is malformed"
+// @failure      404  {object}  errorconverter.Problem
+// @failure      409  {object}  errorconverter.Problem
+// @failure      412  {object}  errorconverter.Problem  "The user was modified since the ETag was read"
+// @failure      428  {object}  errorconverter.Problem  "If-Match is missing"
+// @failure      500  {object}  errorconverter.Problem
+// @router       /user/{id} [put]
+func (h user) Update(c echo.Context) error {
+	match, err := parseIfMatch(c.Request().Header.Get(constant.HEADER_IF_MATCH))
+	if err != nil {
+		return err
+	}
+
+	body := &UpdateRequestBody{}
+	if err := c.Bind(body); err != nil {
+		return bindError(err)
+	}
+	if err := validator.Validate.Struct(body); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	// A single ETag names the version to update. Otherwise the stored version is updated if If-Match allows it.
+	var version int64
+	if !match.any && len(match.versions) == 1 {
+		version = match.versions[0]
+	} else {
+		current, err := h.userUseCase.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !match.matches(current.Version) {
+			return ifMatchFailed()
+		}
+		version = current.Version
+	}
+
+	user := entity.User{
+		ID:        id,
+		Name:      body.Name,
+		Username:  body.Username,
+		BirthDate: body.BirthDate,
+		Version:   version,
+	}
+
+	if _, err := h.userUseCase.Update(ctx, id, &user); err != nil {
+		return conditionalError(err)
+	}
+
+	c.Response().Header().Set(constant.HEADER_ETAG, etag(user.Version))
+
+	return c.JSON(http.StatusOK, newUserResponseBody(user))
+}
+
+// Patch godoc
+// @id           patch-user
+// @summary      Patch a user
+// @description  Change some fields of a user's profile with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902)
+// @description  against the document of UpdateRequestBody. The patched profile must pass the same validation as an
+// @description  update. If-Match must carry the ETag from the last read, a list of ETags, or *.
+// @tags         users
+// @accept       application/merge-patch+json,application/json-patch+json
+// @produce      json
+// @param        id        path    string  true  "User ID"
+// @param        If-Match  header  string  true  "ETag of the user being patched"
+// @param        data      body    object  true  "Merge patch or JSON patch"
+// @success      200  {object}  UserResponseBody
+// @header       200  {string}  ETag  "New user version"
+// @failure      400  {object}  errorconverter.Problem  "The request or its If-Match header is malformed"
+// @failure      404  {object}  errorconverter.Problem
+// @failure      409  {object}  errorconverter.Problem
+// @failure      412  {object}  errorconverter.Problem  "The user was modified since the ETag was read"
+// @failure      415  {object}  errorconverter.Problem
+// @failure      422  {object}  errorconverter.Problem  "The patch cannot be applied to the user"
+// @failure      428  {object}  errorconverter.Problem  "If-Match is missing"
+// @failure      500  {object}  errorconverter.Problem
+// @router       /user/{id} [patch]
+func (h user) Patch(c echo.Context) error {
+	match, err := parseIfMatch(c.Request().Header.Get(constant.HEADER_IF_MATCH))
+	if err != nil {
+		return err
+	}
+
+	var apply func(doc []byte, patch []byte) ([]byte, error)
+	contentType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	switch contentType {
+	case patch.MergePatchContentType:
+		apply = patch.MergePatch
+	case patch.JSONPatchContentType:
+		apply = patch.JSONPatch
+	default:
+		return unsupportedFormat(
+			fmt.Sprintf("Content-Type must be %s or %s", patch.MergePatchContentType, patch.JSONPatchContentType))
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return invalidArgument(err.Error())
+	}
+
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	current, err := h.userUseCase.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !match.matches(current.Version) {
+		return ifMatchFailed()
+	}
+
+	before, err := json.Marshal(&UpdateRequestBody{
+		Name:      current.Name,
+		Username:  current.Username,
+		BirthDate: current.BirthDate,
+	})
+	if err != nil {
+		return err
+	}
+
+	after, err := apply(before, body)
+	if err != nil {
+		return patchError(err)
+	}
+
+	setFields, unsetFields, err := patch.Fields(before, after)
+	if err != nil {
+		return patchError(err)
+	}
+
+	patched := &UpdateRequestBody{}
+	decoder := json.NewDecoder(bytes.NewReader(after))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(patched); err != nil {
+		return unprocessable(fmt.Sprintf("The patched user is invalid: %s", err))
+	}
+	if err := validator.Validate.Struct(patched); err != nil {
+		return err
+	}
+
+	if len(setFields) == 0 && len(unsetFields) == 0 {
+		c.Response().Header().Set(constant.HEADER_ETAG, etag(current.Version))
+		return c.JSON(http.StatusOK, newUserResponseBody(current))
+	}
+
+	values := map[string]interface{}{
+		"name":       patched.Name,
+		"username":   patched.Username,
+		"birth_date": patched.BirthDate,
+	}
+	set := make(map[string]interface{}, len(setFields))
+	for _, field := range setFields {
+		set[field] = values[field]
+	}
+
+	user, err := h.userUseCase.Patch(ctx, id, current.Version, set, unsetFields)
+	if err != nil {
+		return conditionalError(err)
+	}
+
+	c.Response().Header().Set(constant.HEADER_ETAG, etag(user.Version))
+
+	return c.JSON(http.StatusOK, newUserResponseBody(user))
+}
+
+// Delete godoc
+// @id           delete-user
+// @summary      Delete a user
+// @description  Delete a user. The user can be restored by an admin until it is purged
+// @tags         users
+// @param        id  path  string  true  "User ID"
+// @success      204
+// @failure      400  {object}  errorconverter.Problem
+// @failure      404  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /user/{id} [delete]
+func (h user) Delete(c echo.Context) error {
+	id := c.Param("id")
+
+	if err := h.userUseCase.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	h.logger.Info(c.Request().Context(), "user deleted", log.String("userID", id))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Restore godoc
+// @id           restore-user
+// @summary      Restore a deleted user
+// @description  Undo the deletion of a user that has not been purged yet
+// @tags         admin
+// @produce      json
+// @param        id  path  string  true  "User ID"
+// @success      200  {object}  UserResponseBody
+// @header       200  {string}  ETag  "User version"
+// @failure      400  {object}  errorconverter.Problem
+// @failure      404  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /admin/users/{id}/restore [post]
+func (h user) Restore(c echo.Context) error {
+	id := c.Param("id")
+
+	user, err := h.userUseCase.Restore(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info(c.Request().Context(), "deleted user restored", log.String("userID", id))
+
+	c.Response().Header().Set(constant.HEADER_ETAG, etag(user.Version))
+
+	return c.JSON(http.StatusOK, newUserResponseBody(user))
+}
+
+type GetAuditRequestQuery struct {
+	Limit  int64  `query:"limit" json:"limit" validate:"min=1,max=100"`
+	Cursor string `query:"cursor" json:"cursor"`
+}
+
+type GetAuditResponseBody struct {
+	Data   []entity.AuditRecord `json:"data"`
+	Paging PagingResponseBody   `json:"paging"`
+}
+
+// GetAudit godoc
+// @id           get-user-audit
+// @summary      Show the audit trail of a user
+// @description  Show a page of the changes made to a user, newest first. Passwords are redacted
+// @tags         users
+// @produce      json
+// @param        id      path   string  true   "User ID"
+// @param        limit   query  int     false  "Page size"  default(20)
+// @param        cursor  query  string  false  "Cursor from the previous page"
+// @success      200  {object}  GetAuditResponseBody
+// @failure      400  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /user/{id}/audit [get]
+func (h user) GetAudit(c echo.Context) error {
+	query := &GetAuditRequestQuery{Limit: 20}
+	if err := c.Bind(query); err != nil {
+		return bindError(err)
+	}
+	if err := validator.Validate.Struct(query); err != nil {
+		return err
+	}
+
+	records, pageInfo, err := h.userUseCase.GetAudit(c.Request().Context(), c.Param("id"), adapter.Pagination{
+		Limit:  query.Limit,
+		Cursor: query.Cursor,
 	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &GetAuditResponseBody{
+		Data: records,
+		Paging: PagingResponseBody{
+			Limit:      query.Limit,
+			NextCursor: pageInfo.NextCursor,
+			HasMore:    pageInfo.HasMore,
+		},
+	})
+}
+
+func newUserResponseBody(user entity.User) UserResponseBody {
+	return UserResponseBody{
+		ID:        user.ID,
+		Name:      user.Name,
+		Username:  user.Username,
+		BirthDate: user.BirthDate,
+	}
 }
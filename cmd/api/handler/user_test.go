@@ -0,0 +1,103 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/cmd/api/errorconverter"
+	"github.com/wisesight/go-api-template/cmd/api/handler"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/query"
+	"github.com/wisesight/go-api-template/pkg/usecase/mocks"
+	"github.com/wisesight/go-api-template/pkg/validator"
+)
+
+type UserHandlerSuite struct {
+	suite.Suite
+	userUseCase *mocks.IUser
+	echo        *echo.Echo
+}
+
+func (s *UserHandlerSuite) SetupSuite() {
+	s.Require().NoError(validator.NewValidator())
+}
+
+func (s *UserHandlerSuite) SetupTest() {
+	logger, err := log.NewLoggerZap(&log.ZapConfig{})
+	s.Require().NoError(err)
+
+	s.userUseCase = &mocks.IUser{}
+	userHandler := handler.NewUser(s.userUseCase, logger)
+
+	s.echo = echo.New()
+	s.echo.HTTPErrorHandler = errorconverter.NewHTTPErrorHandler(errorconverter.HTTPErrorHandlerConfig{}, logger)
+	s.echo.GET("/user", userHandler.GetAll)
+	s.echo.POST("/user:import", userHandler.Import)
+	s.echo.GET("/user:export", userHandler.Export)
+}
+
+func TestUserHandlerSuite(t *testing.T) {
+	suite.Run(t, new(UserHandlerSuite))
+}
+
+// serve sends a request through the handlers and the error handler and decodes the problem of a failed one.
+func (s *UserHandlerSuite) serve(req *http.Request) (*httptest.ResponseRecorder, errorconverter.Problem) {
+	rec := httptest.NewRecorder()
+	s.echo.ServeHTTP(rec, req)
+
+	var problem errorconverter.Problem
+	if rec.Code >= http.StatusBadRequest {
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &problem))
+	}
+	return rec, problem
+}
+
+func (s *UserHandlerSuite) TestGetAll() {
+	s.Run("should pass the parsed filter to the usecase", func() {
+		var q query.Query
+		s.userUseCase.On("GetAll", mock.Anything, mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { q = args.Get(1).(query.Query) }).
+			Return([]entity.User{}, adapter.PageInfo{}, nil).Once()
+
+		rec, _ := s.serve(httptest.NewRequest(http.MethodGet, "/user?name[prefix]=Jo&birth_date[gte]=2000-01-01&q=doe", nil))
+
+		s.Equal(http.StatusOK, rec.Code)
+		s.Equal([]query.Condition{
+			{Path: "birth_date", Operator: query.GreaterOrEqual, Value: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)},
+			{Path: "name", Operator: query.Prefix, Value: "Jo"},
+		}, q.Conditions)
+		s.Equal("doe", q.Text)
+	})
+
+	rejected := map[string]url.Values{
+		"a field outside the allowlist":        {"password": {"secret"}},
+		"a MongoDB operator as a field":        {"$where": {"sleep(1000)"}},
+		"a MongoDB operator as an operator":    {"name[$ne]": {"x"}},
+		"an operator the field does not allow": {"name[gt]": {"a"}},
+		"an unknown operator":                  {"username[regex]": {".*"}},
+		"a nested field":                       {"name.first": {"Jo"}},
+		"a value that is not a date":           {"birth_date[gte]": {"yesterday"}},
+		"a sort outside the allowlist":         {"sort": {"password"}},
+	}
+	for name, params := range rejected {
+		s.Run("should reject "+name+" with 400", func() {
+			s.SetupTest()
+
+			rec, problem := s.serve(httptest.NewRequest(http.MethodGet, "/user?"+params.Encode(), nil))
+
+			s.Equal(http.StatusBadRequest, rec.Code)
+			s.Equal("INVALID_ARGUMENT", problem.Code)
+			s.NotEmpty(problem.Detail)
+			s.userUseCase.AssertNotCalled(s.T(), "GetAll", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,452 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	gpgvalidator "github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/query"
+	"github.com/wisesight/go-api-template/pkg/validator"
+)
+
+const (
+	mimeNDJSON = "application/x-ndjson"
+	mimeCSV    = "text/csv"
+
+	// importBatchSize is how many valid rows are created per transaction.
+	importBatchSize = 500
+	// importMaxLineSize bounds an NDJSON line or a CSV record, so a single row cannot be read into memory whatever
+	// its size.
+	importMaxLineSize = 64 << 10
+	// exportFlushEvery is how many users are written between flushes to the client.
+	exportFlushEvery = 500
+)
+
+// importColumns are the CSV columns of an import, in any order.
+var importColumns = []string{"name", "username", "password", "birth_date"}
+
+// exportColumns are the CSV columns of an export.
+var exportColumns = []string{"id", "name", "username", "birth_date"}
+
+type ImportRowError struct {
+	// Row is the 1-based position of the record, not counting a CSV header.
+	Row    int      `json:"row" example:"2"`
+	Errors []string `json:"errors" example:"username is a required field"`
+}
+
+type ImportResponseBody struct {
+	Created int              `json:"created" example:"998"`
+	Failed  int              `json:"failed" example:"2"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+// Import godoc
+// @id           import-users
+// @summary      Import users
+// @description  Create users from an NDJSON stream of CreateRequestBody objects, or from a CSV with a header row of
+// @description  name, username, password and birth_date. Rows are validated like a create and stored in batches;
+// @description  invalid rows and taken usernames are reported and skipped without stopping the import.
+// @tags         users
+// @accept       application/x-ndjson,text/csv
+// @produce      json
+// @param        data  body  string  true  "Users, one per line or CSV record"
+// @success      200  {object}  ImportResponseBody
+// @failure      400  {object}  errorconverter.Problem
+// @failure      415  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /user:import [post]
+func (h user) Import(c echo.Context) error {
+	var next func() (*CreateRequestBody, error)
+
+	contentType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	switch contentType {
+	case mimeNDJSON, "application/ndjson":
+		next = ndjsonRows(c.Request().Body)
+	case mimeCSV:
+		var err error
+		if next, err = csvRows(c.Request().Body); err != nil {
+			return invalidArgument(err.Error())
+		}
+	default:
+		return unsupportedFormat(fmt.Sprintf("Content-Type must be %s or %s", mimeNDJSON, mimeCSV))
+	}
+
+	ctx := c.Request().Context()
+	report := &ImportResponseBody{Errors: []ImportRowError{}}
+	fail := func(row int, messages ...string) {
+		report.Failed++
+		report.Errors = append(report.Errors, ImportRowError{Row: row, Errors: messages})
+	}
+
+	var (
+		batch []entity.User
+		rows  []int
+	)
+	flush := func() {
+		for i, err := range h.userUseCase.CreateMany(ctx, batch) {
+			if err != nil {
+				fail(rows[i], rowErrorMessage(err))
+			} else {
+				report.Created++
+			}
+		}
+		batch, rows = batch[:0], rows[:0]
+	}
+
+	for row := 1; ; row++ {
+		body, err := next()
+		if err == io.EOF {
+			break
+		}
+
+		var rowErr *importRowError
+		if errors.As(err, &rowErr) {
+			fail(row, rowErr.Error())
+			continue
+		}
+		if err != nil {
+			// The rest of the stream cannot be read, so the import stops here.
+			fail(row, err.Error())
+			break
+		}
+
+		if err := validator.Validate.Struct(body); err != nil {
+			fail(row, validationMessages(err)...)
+			continue
+		}
+
+		batch = append(batch, entity.User{
+			Name:      body.Name,
+			Username:  body.Username,
+			Password:  body.Password,
+			BirthDate: body.BirthDate,
+		})
+		rows = append(rows, row)
+		if len(batch) == importBatchSize {
+			flush()
+		}
+	}
+	if len(batch) > 0 {
+		flush()
+	}
+
+	h.logger.Info(ctx, "users imported", log.Int("created", report.Created), log.Int("failed", report.Failed))
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// importRowError is a row that cannot be read, after which the next rows still can.
+type importRowError struct {
+	message string
+}
+
+func (e *importRowError) Error() string {
+	return e.message
+}
+
+// ndjsonRows reads one CreateRequestBody per line, skipping blank lines. Lines longer than importMaxLineSize are
+// skipped and reported as invalid.
+func ndjsonRows(r io.Reader) func() (*CreateRequestBody, error) {
+	reader := bufio.NewReaderSize(r, importMaxLineSize)
+	return func() (*CreateRequestBody, error) {
+		for {
+			line, err := reader.ReadSlice('\n')
+			if err == bufio.ErrBufferFull {
+				if err := discardLine(reader); err != nil && err != io.EOF {
+					return nil, err
+				}
+				return nil, &importRowError{message: fmt.Sprintf("line is longer than %d bytes", importMaxLineSize)}
+			}
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if len(bytes.TrimSpace(line)) == 0 {
+				if err == io.EOF {
+					return nil, io.EOF
+				}
+				continue
+			}
+
+			body := &CreateRequestBody{}
+			decoder := json.NewDecoder(bytes.NewReader(line))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(body); err != nil {
+				return nil, &importRowError{message: fmt.Sprintf("invalid JSON: %s", err)}
+			}
+			if _, err := decoder.Token(); err != io.EOF {
+				return nil, &importRowError{message: "invalid JSON: unexpected data after the object"}
+			}
+			return body, nil
+		}
+	}
+}
+
+// discardLine skips the rest of the current line.
+func discardLine(reader *bufio.Reader) error {
+	for {
+		_, err := reader.ReadSlice('\n')
+		if err != bufio.ErrBufferFull {
+			return err
+		}
+	}
+}
+
+// errRecordTooLong is returned by recordLimitReader once a record passes importMaxLineSize.
+var errRecordTooLong = fmt.Errorf("record is longer than %d bytes", importMaxLineSize)
+
+// recordLimitReader fails once more than limit bytes are read since the last reset. csv.Reader buffers ahead, so
+// the bytes counted for a record may include up to one buffer of the records after it.
+type recordLimitReader struct {
+	reader io.Reader
+	limit  int
+	read   int
+}
+
+func (r *recordLimitReader) Read(p []byte) (int, error) {
+	if r.read >= r.limit {
+		return 0, errRecordTooLong
+	}
+	if len(p) > r.limit-r.read {
+		p = p[:r.limit-r.read]
+	}
+	n, err := r.reader.Read(p)
+	r.read += n
+	return n, err
+}
+
+func (r *recordLimitReader) reset() {
+	r.read = 0
+}
+
+// csvRows reads the header, then one CreateRequestBody per record. A record longer than importMaxLineSize ends the
+// import, as an unterminated quoted field leaves no way to find where the next record starts.
+func csvRows(r io.Reader) (func() (*CreateRequestBody, error), error) {
+	limited := &recordLimitReader{reader: r, limit: importMaxLineSize}
+	reader := csv.NewReader(limited)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if !contains(importColumns, column) {
+			return nil, fmt.Errorf("unknown CSV column %q, expected %s", column, strings.Join(importColumns, ", "))
+		}
+		columns[column] = i
+	}
+	for _, column := range importColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("missing CSV column %q", column)
+		}
+	}
+
+	return func() (*CreateRequestBody, error) {
+		limited.reset()
+		record, err := reader.Read()
+		if errors.Is(err, errRecordTooLong) {
+			return nil, errRecordTooLong
+		}
+		if errors.Is(err, csv.ErrFieldCount) {
+			return nil, &importRowError{message: err.Error()}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		body := &CreateRequestBody{
+			Name:     record[columns["name"]],
+			Username: record[columns["username"]],
+			Password: record[columns["password"]],
+		}
+		if birthDate := record[columns["birth_date"]]; birthDate != "" {
+			if body.BirthDate, err = parseDate(birthDate); err != nil {
+				return nil, &importRowError{message: "birth_date must be an RFC 3339 time or a date such as 2006-01-02"}
+			}
+		}
+		return body, nil
+	}, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// validationMessages lists the translated messages of a validation error, sorted.
+func validationMessages(err error) []string {
+	var errs gpgvalidator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return []string{err.Error()}
+	}
+
+	messages := []string{}
+	for _, message := range errs.Translate(validator.Trans) {
+		messages = append(messages, message)
+	}
+	sort.Strings(messages)
+	return messages
+}
+
+func rowErrorMessage(err error) string {
+	var appErr apperror.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Message
+	}
+	return err.Error()
+}
+
+// Export godoc
+// @id           export-users
+// @summary      Export users
+// @description  Stream every user matching the filters of GET /user as NDJSON, or as CSV when format is csv or
+// @description  Accept prefers text/csv. Passwords are never exported.
+// @tags         users
+// @produce      application/x-ndjson,text/csv
+// @param        format  query  string  false  "ndjson or csv"
+// @success      200  {string}  string  "One UserResponseBody per line, or CSV with a header row"
+// @failure      400  {object}  errorconverter.Problem
+// @failure      500  {object}  errorconverter.Problem
+// @router       /user:export [get]
+func (h user) Export(c echo.Context) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "ndjson"
+		if strings.Contains(c.Request().Header.Get(echo.HeaderAccept), mimeCSV) {
+			format = "csv"
+		}
+	}
+	if format != "ndjson" && format != "csv" {
+		return invalidArgument("format must be ndjson or csv")
+	}
+
+	filter, err := query.Parse(userQuerySchema, c.QueryParams(), "format")
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	response := c.Response()
+
+	var encoder userEncoder
+	start := func() error {
+		if encoder != nil {
+			return nil
+		}
+		if format == "csv" {
+			response.Header().Set(echo.HeaderContentType, mimeCSV+"; charset=utf-8")
+			response.WriteHeader(http.StatusOK)
+			encoder = &csvUserEncoder{writer: csv.NewWriter(response)}
+		} else {
+			response.Header().Set(echo.HeaderContentType, mimeNDJSON)
+			response.WriteHeader(http.StatusOK)
+			encoder = &ndjsonUserEncoder{encoder: json.NewEncoder(response)}
+		}
+		return encoder.begin()
+	}
+
+	count := 0
+	err = h.userUseCase.Export(ctx, filter, func(user entity.User) error {
+		if err := start(); err != nil {
+			return err
+		}
+		if err := encoder.encode(newUserResponseBody(user)); err != nil {
+			return err
+		}
+
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := encoder.flush(); err != nil {
+				return err
+			}
+			response.Flush()
+		}
+		return nil
+	})
+
+	if err != nil {
+		if encoder == nil {
+			return err
+		}
+		// The status is already sent, so the client only sees the stream end early.
+		h.logger.Error(ctx, "user export failed", log.Int("count", count), log.Error(err))
+		return nil
+	}
+
+	if err := start(); err != nil {
+		return err
+	}
+	if err := encoder.flush(); err != nil {
+		return err
+	}
+	response.Flush()
+
+	h.logger.Info(ctx, "users exported", log.Int("count", count))
+
+	return nil
+}
+
+type userEncoder interface {
+	begin() error
+	encode(user UserResponseBody) error
+	flush() error
+}
+
+type ndjsonUserEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonUserEncoder) begin() error {
+	return nil
+}
+
+func (e *ndjsonUserEncoder) encode(user UserResponseBody) error {
+	return e.encoder.Encode(user)
+}
+
+func (e *ndjsonUserEncoder) flush() error {
+	return nil
+}
+
+type csvUserEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvUserEncoder) begin() error {
+	return e.writer.Write(exportColumns)
+}
+
+func (e *csvUserEncoder) encode(user UserResponseBody) error {
+	return e.writer.Write([]string{user.ID, user.Name, user.Username, user.BirthDate.Format(time.RFC3339)})
+}
+
+func (e *csvUserEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
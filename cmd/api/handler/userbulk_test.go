@@ -0,0 +1,272 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/wisesight/go-api-template/cmd/api/handler"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/query"
+)
+
+const (
+	validNDJSONRow = `{"name":"John","username":"john","password":"A1b2C3d$","birth_date":"2000-01-01T00:00:00Z"}`
+	validCSVRow    = `John,john,A1b2C3d$,2000-01-01`
+)
+
+// createMany makes CreateMany succeed and returns the size of every batch it was called with.
+func (s *UserHandlerSuite) createMany() *[]int {
+	batches := &[]int{}
+	s.userUseCase.On("CreateMany", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, users []entity.User) []error {
+			*batches = append(*batches, len(users))
+			return make([]error, len(users))
+		},
+	)
+	return batches
+}
+
+func (s *UserHandlerSuite) importUsers(contentType string, body string) (*httptest.ResponseRecorder, handler.ImportResponseBody) {
+	req := httptest.NewRequest(http.MethodPost, "/user:import", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, contentType)
+
+	rec, _ := s.serve(req)
+
+	var report handler.ImportResponseBody
+	if rec.Code == http.StatusOK {
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &report))
+	}
+	return rec, report
+}
+
+func (s *UserHandlerSuite) TestImport() {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		created     int
+		errors      []handler.ImportRowError
+	}{
+		{
+			name:        "should create every valid NDJSON row",
+			contentType: "application/x-ndjson",
+			body:        validNDJSONRow + "\n" + validNDJSONRow + "\n",
+			created:     2,
+			errors:      []handler.ImportRowError{},
+		},
+		{
+			name:        "should skip blank NDJSON lines without counting them as rows",
+			contentType: "application/x-ndjson",
+			body:        "\n" + validNDJSONRow + "\n   \n{\"name\":\"Jane\",\"username\":\"jane\"}\n\n",
+			created:     1,
+			errors: []handler.ImportRowError{{Row: 2, Errors: []string{
+				"birth_date is a required field", "password is a required field",
+			}}},
+		},
+		{
+			name:        "should report invalid JSON and unknown fields by 1-based row",
+			contentType: "application/x-ndjson",
+			body:        "{\n" + validNDJSONRow + "\n{\"email\":\"a@b.c\"}",
+			created:     1,
+			errors: []handler.ImportRowError{
+				{Row: 1, Errors: []string{"invalid JSON: unexpected EOF"}},
+				{Row: 3, Errors: []string{`invalid JSON: json: unknown field "email"`}},
+			},
+		},
+		{
+			name:        "should report data after the object on an NDJSON line",
+			contentType: "application/x-ndjson",
+			body:        validNDJSONRow + " " + validNDJSONRow + "\n" + validNDJSONRow + "garbage\n" + validNDJSONRow + " \r\n",
+			created:     1,
+			errors: []handler.ImportRowError{
+				{Row: 1, Errors: []string{"invalid JSON: unexpected data after the object"}},
+				{Row: 2, Errors: []string{"invalid JSON: unexpected data after the object"}},
+			},
+		},
+		{
+			name:        "should report an NDJSON line longer than the limit and read on",
+			contentType: "application/x-ndjson",
+			body:        `{"name":"` + strings.Repeat("a", 64<<10) + "\"}\n" + validNDJSONRow,
+			created:     1,
+			errors:      []handler.ImportRowError{{Row: 1, Errors: []string{"line is longer than 65536 bytes"}}},
+		},
+		{
+			name:        "should read CSV columns in any order",
+			contentType: "text/csv",
+			body:        "birth_date,password,username,name\n2000-01-01,A1b2C3d$,john,John\n",
+			created:     1,
+			errors:      []handler.ImportRowError{},
+		},
+		{
+			name:        "should report CSV records with the wrong number of fields and read on",
+			contentType: "text/csv",
+			body:        "name,username,password,birth_date\nJohn,john\n" + validCSVRow + "\n",
+			created:     1,
+			errors:      []handler.ImportRowError{{Row: 1, Errors: []string{"record on line 2: wrong number of fields"}}},
+		},
+		{
+			name:        "should report a CSV record longer than the limit and stop",
+			contentType: "text/csv",
+			body:        "name,username,password,birth_date\n" + validCSVRow + "\n\"" + strings.Repeat("a", 1<<20),
+			created:     1,
+			errors:      []handler.ImportRowError{{Row: 2, Errors: []string{"record is longer than 65536 bytes"}}},
+		},
+		{
+			name:        "should report a CSV birth date that is not a date",
+			contentType: "text/csv",
+			body:        "name,username,password,birth_date\nJohn,john,A1b2C3d$,yesterday\n",
+			created:     0,
+			errors: []handler.ImportRowError{{Row: 1, Errors: []string{
+				"birth_date must be an RFC 3339 time or a date such as 2006-01-02",
+			}}},
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.SetupTest()
+			s.createMany()
+
+			rec, report := s.importUsers(test.contentType, test.body)
+
+			s.Require().Equal(http.StatusOK, rec.Code, rec.Body.String())
+			s.Equal(test.created, report.Created)
+			s.Equal(len(test.errors), report.Failed)
+			s.Equal(test.errors, report.Errors)
+		})
+	}
+
+	rejected := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		detail      string
+	}{
+		{"should reject a body that is neither NDJSON nor CSV", "application/json", "[]", http.StatusUnsupportedMediaType, "Content-Type must be application/x-ndjson or text/csv"},
+		{"should reject a CSV without a header", "text/csv", "", http.StatusBadRequest, "invalid CSV header: EOF"},
+		{"should reject an unknown CSV column", "text/csv", "name,username,password,birth_date,email\n", http.StatusBadRequest, `unknown CSV column "email", expected name, username, password, birth_date`},
+		{"should reject a missing CSV column", "text/csv", "name,username,password\n", http.StatusBadRequest, `missing CSV column "birth_date"`},
+	}
+	for _, test := range rejected {
+		s.Run(test.name, func() {
+			s.SetupTest()
+
+			req := httptest.NewRequest(http.MethodPost, "/user:import", strings.NewReader(test.body))
+			req.Header.Set(echo.HeaderContentType, test.contentType)
+			rec, problem := s.serve(req)
+
+			s.Equal(test.status, rec.Code)
+			s.Equal(test.detail, problem.Detail)
+			s.userUseCase.AssertNotCalled(s.T(), "CreateMany", mock.Anything, mock.Anything)
+		})
+	}
+
+	s.Run("should create the users in batches of 500", func() {
+		s.SetupTest()
+		batches := s.createMany()
+
+		rec, report := s.importUsers("application/x-ndjson", strings.Repeat(validNDJSONRow+"\n", 1001))
+
+		s.Equal(http.StatusOK, rec.Code)
+		s.Equal(1001, report.Created)
+		s.Equal([]int{500, 500, 1}, *batches)
+	})
+
+	s.Run("should report the rows the usecase failed to create", func() {
+		s.SetupTest()
+		s.userUseCase.On("CreateMany", mock.Anything, mock.Anything).Return([]error{
+			nil,
+			apperror.NewError("User already exists", "The username is already taken", apperror.DuplicateKey),
+		})
+
+		rec, report := s.importUsers("text/csv", "name,username,password,birth_date\n"+validCSVRow+"\n"+validCSVRow+"\n")
+
+		s.Equal(http.StatusOK, rec.Code)
+		s.Equal(1, report.Created)
+		s.Equal([]handler.ImportRowError{{Row: 2, Errors: []string{"User already exists"}}}, report.Errors)
+	})
+}
+
+func (s *UserHandlerSuite) TestExport() {
+	users := []entity.User{
+		{ID: "1", Name: "John", Username: "john", Password: "hash", BirthDate: time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "2", Name: "Jane", Username: "jane", Password: "hash", BirthDate: time.Date(2001, time.February, 3, 0, 0, 0, 0, time.UTC)},
+	}
+	export := func() *query.Query {
+		var q query.Query
+		s.userUseCase.On("Export", mock.Anything, mock.Anything, mock.Anything).Return(
+			func(ctx context.Context, filter query.Query, fn func(entity.User) error) error {
+				q = filter
+				for _, user := range users {
+					if err := fn(user); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		)
+		return &q
+	}
+
+	ndjson := `{"id":"1","name":"John","username":"john","birth_date":"2000-01-01T00:00:00Z"}` + "\n" +
+		`{"id":"2","name":"Jane","username":"jane","birth_date":"2001-02-03T00:00:00Z"}` + "\n"
+	csv := "id,name,username,birth_date\n1,John,john,2000-01-01T00:00:00Z\n2,Jane,jane,2001-02-03T00:00:00Z\n"
+
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"should export NDJSON by default", "/user:export", "", "application/x-ndjson", ndjson},
+		{"should export CSV when format is csv", "/user:export?format=csv", "", "text/csv; charset=utf-8", csv},
+		{"should export CSV when Accept prefers it", "/user:export", "text/csv", "text/csv; charset=utf-8", csv},
+		{"should let format override Accept", "/user:export?format=ndjson", "text/csv", "application/x-ndjson", ndjson},
+	}
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			s.SetupTest()
+			export()
+
+			req := httptest.NewRequest(http.MethodGet, test.target, nil)
+			req.Header.Set(echo.HeaderAccept, test.accept)
+			rec, _ := s.serve(req)
+
+			s.Equal(http.StatusOK, rec.Code)
+			s.Equal(test.contentType, rec.Header().Get(echo.HeaderContentType))
+			s.Equal(test.body, rec.Body.String())
+			s.NotContains(rec.Body.String(), "hash")
+		})
+	}
+
+	s.Run("should pass the filters of GET /user to the usecase", func() {
+		s.SetupTest()
+		q := export()
+
+		rec, _ := s.serve(httptest.NewRequest(http.MethodGet, "/user:export?format=csv&username=john", nil))
+
+		s.Equal(http.StatusOK, rec.Code)
+		s.Equal([]query.Condition{{Path: "username", Operator: query.Equal, Value: "john"}}, q.Conditions)
+	})
+
+	for _, target := range []string{"/user:export?format=xml", "/user:export?password=x"} {
+		s.Run(fmt.Sprintf("should reject %s with 400", target), func() {
+			s.SetupTest()
+
+			rec, _ := s.serve(httptest.NewRequest(http.MethodGet, target, nil))
+
+			s.Equal(http.StatusBadRequest, rec.Code)
+			s.userUseCase.AssertNotCalled(s.T(), "Export", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
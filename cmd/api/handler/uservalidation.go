@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"strconv"
 	"strings"
 
 	ut "github.com/go-playground/universal-translator"
@@ -10,6 +11,7 @@ import (
 
 const (
 	isValidPasswordTag = "is_valid_password"
+	maxBytesTag        = "max_bytes"
 )
 
 func isValidPassword(fl gpgvalidator.FieldLevel) bool {
@@ -21,10 +23,20 @@ func isValidPassword(fl gpgvalidator.FieldLevel) bool {
 	return !strings.Contains(password, username)
 }
 
+// maxBytes limits the length of a string in bytes, where max counts runes.
+func maxBytes(fl gpgvalidator.FieldLevel) bool {
+	limit, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		return false
+	}
+	return len(fl.Field().String()) <= limit
+}
+
 func newUserValidation() error {
 	errors := []error{
 		// Validation
 		validator.Validate.RegisterValidation(isValidPasswordTag, isValidPassword),
+		validator.Validate.RegisterValidation(maxBytesTag, maxBytes),
 
 		// Translation
 		validator.Validate.RegisterTranslation(
@@ -38,6 +50,17 @@ func newUserValidation() error {
 				return t
 			},
 		),
+		validator.Validate.RegisterTranslation(
+			maxBytesTag,
+			validator.Trans,
+			func(ut ut.Translator) error {
+				return ut.Add(maxBytesTag, "{0} must be at most {1} bytes", true)
+			},
+			func(ut ut.Translator, fe gpgvalidator.FieldError) string {
+				t, _ := ut.T(maxBytesTag, fe.Field(), fe.Param())
+				return t
+			},
+		),
 	}
 
 	for _, err := range errors {
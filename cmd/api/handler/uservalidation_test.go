@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	gpgvalidator "github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/validator"
+)
+
+type UserValidationSuite struct {
+	suite.Suite
+}
+
+func (s *UserValidationSuite) SetupSuite() {
+	s.Require().NoError(validator.NewValidator())
+	s.Require().NoError(newUserValidation())
+}
+
+func TestUserValidationSuite(t *testing.T) {
+	suite.Run(t, new(UserValidationSuite))
+}
+
+func (s *UserValidationSuite) body(password string) CreateRequestBody {
+	return CreateRequestBody{
+		Name:      "name",
+		Username:  "username",
+		Password:  password,
+		BirthDate: time.Now(),
+	}
+}
+
+func (s *UserValidationSuite) TestMaxBytes() {
+	s.Run("should accept a password of 72 bytes", func() {
+		s.NoError(validator.Validate.Struct(s.body(strings.Repeat("a", 72))))
+	})
+
+	s.Run("should reject a password longer than 72 bytes", func() {
+		err := validator.Validate.Struct(s.body(strings.Repeat("a", 73)))
+
+		var errs gpgvalidator.ValidationErrors
+		s.Require().ErrorAs(err, &errs)
+		s.Equal("password must be at most 72 bytes", errs[0].Translate(validator.Trans))
+	})
+
+	s.Run("should count bytes rather than runes", func() {
+		// 25 runes of 3 bytes each.
+		err := validator.Validate.Struct(s.body(strings.Repeat("ก", 25)))
+
+		var errs gpgvalidator.ValidationErrors
+		s.Require().ErrorAs(err, &errs)
+		s.Equal(maxBytesTag, errs[0].Tag())
+	})
+}
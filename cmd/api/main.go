@@ -10,14 +10,16 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"github.com/wisesight/go-api-template/cmd/api/handler"
+	"github.com/wisesight/go-api-template/cmd/api/errorconverter"
 	"github.com/wisesight/go-api-template/cmd/api/middleware"
 	"github.com/wisesight/go-api-template/cmd/api/route"
+	"github.com/wisesight/go-api-template/cmd/internal/dependency"
 	"github.com/wisesight/go-api-template/config"
 	"github.com/wisesight/go-api-template/pkg/adapter"
 	"github.com/wisesight/go-api-template/pkg/log"
 	"github.com/wisesight/go-api-template/pkg/repository"
 	"github.com/wisesight/go-api-template/pkg/validator"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // @title Wisesight API Template
@@ -55,14 +57,9 @@ func main() {
 		panic(err)
 	}
 
-	userCollection := mongodbClient.Database("test").Collection("users")
+	database := mongodbClient.Database(cfg.MongoDBDatabase)
 	mongoDBAdapter := adapter.NewMongoDBAdapter(mongodbClient)
 
-	userConfig := repository.UserConfig{
-		Timeout: 10 * time.Second,
-	}
-	repository.NewUser(userConfig, mongoDBAdapter, userCollection)
-
 	app := echo.New()
 
 	logger, err := log.NewLoggerZap(&log.ZapConfig{Debug: true})
@@ -70,16 +67,25 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+
+	app.HTTPErrorHandler = errorconverter.NewHTTPErrorHandler(errorconverter.HTTPErrorHandlerConfig{}, logger)
+
+	if cfg.IndexSyncOnStartup {
+		syncIndexes(cfg, mongoDBAdapter, database, logger)
+	}
+
 	app.Use(middleware.RequestID())
 	app.Use(middleware.RequestLoggerMiddleware(logger))
 	app.Use(middleware.ResponseLoggerMiddleware(logger))
 	app.Use(middleware.SecurityMiddleware())
 	app.Use(middleware.CorsMiddleware())
 
-	userHandler := handler.NewUser(logger)
-	probeHandler := handler.NewProbe(mongoDBAdapter, logger)
+	usecases, err := dependency.NewUsecases(cfg, mongoDBAdapter, dependency.NewRepositories(cfg, 10*time.Second, mongoDBAdapter, database))
+	if err != nil {
+		panic(err)
+	}
 
-	route.NewRoute(cfg, app, userHandler, probeHandler)
+	route.NewRoute(cfg, app, newHandlers(cfg, mongoDBAdapter, usecases, logger))
 
 	err = app.Start(":4231")
 	if err != nil {
@@ -99,3 +105,31 @@ func main() {
 		}
 	}
 }
+
+// syncIndexes creates missing indexes. Changed and extra indexes are only logged; use the cli to replace them.
+func syncIndexes(cfg config.Config, mongoDBAdapter adapter.IMongoDBAdapter, database *mongo.Database, logger log.ILogger) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	indexSpecs := repository.Indexes(repository.IndexConfig{
+		UserCollection:          cfg.UserCollection,
+		JobCollection:           cfg.JobCollection,
+		JobDeadLetterCollection: cfg.JobDeadLetterCollection,
+		OutboxCollection:        cfg.OutboxCollection,
+		AuditCollection:         cfg.AuditCollection,
+	}, database)
+
+	for _, spec := range indexSpecs {
+		diff, err := mongoDBAdapter.SyncIndexes(ctx, spec, adapter.IndexSyncOptions{})
+		if err != nil {
+			logger.Fatal(ctx, "failed to sync indexes", log.String("collection", spec.Collection.Name()), log.Error(err))
+		}
+		if len(diff.Changed) > 0 || len(diff.Extra) > 0 {
+			logger.Warn(ctx, "indexes differ from their declaration",
+				log.String("collection", diff.Collection),
+				log.Any("changed", diff.Changed),
+				log.Any("extra", diff.Extra),
+			)
+		}
+	}
+}
@@ -34,6 +34,7 @@ func ExtractJWTClaims(next echo.HandlerFunc) echo.HandlerFunc {
 		mapstructure.Decode(claims, &user)
 
 		c.Set(constant.JWT_CONTEXT_KEY, user)
+		c.SetRequest(c.Request().WithContext(entity.ContextWithUserSession(c.Request().Context(), user)))
 		return next(c)
 	}
 }
@@ -5,13 +5,15 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/wisesight/go-api-template/constant"
 )
 
 func CorsMiddleware() echo.MiddlewareFunc {
 	return middleware.CORSWithConfig(middleware.CORSConfig{
-		Skipper:      middleware.DefaultSkipper,
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{echo.HeaderContentType},
-		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodDelete},
+		Skipper:       middleware.DefaultSkipper,
+		AllowOrigins:  []string{"*"},
+		AllowHeaders:  []string{echo.HeaderContentType, constant.HEADER_IF_MATCH},
+		AllowMethods:  []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		ExposeHeaders: []string{constant.HEADER_ETAG},
 	})
 }
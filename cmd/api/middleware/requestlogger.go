@@ -1,12 +1,20 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
+	"mime"
+	"strings"
 
 	"github.com/labstack/echo/v4"
+	"github.com/wisesight/go-api-template/pkg/entity"
 	"github.com/wisesight/go-api-template/pkg/log"
 )
 
+// redactedBodyFields are replaced in logged JSON bodies, at any depth.
+var redactedBodyFields = []string{"password"}
+
 func RequestLoggerMiddleware(logger log.ILogger) echo.MiddlewareFunc {
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -15,12 +23,16 @@ func RequestLoggerMiddleware(logger log.ILogger) echo.MiddlewareFunc {
 
 			var body []byte
 
-			if c.Request().Body != nil {
+			// Only JSON bodies are logged: they can be redacted, and streamed uploads such as imports are not held in
+			// memory.
+			if c.Request().Body != nil && isJSON(request.Header.Get(echo.HeaderContentType)) {
 				body, _ = io.ReadAll(request.Body)
+				// Put the body back for the handler to bind.
+				request.Body = io.NopCloser(bytes.NewBuffer(body))
 			}
 
 			logger.Info(request.Context(), "request",
-				log.String("body", string(body)),
+				log.String("body", redactBody(body)),
 				log.String("method", request.Method),
 				log.String("uri", request.RequestURI),
 				log.String("remoteIP", request.RemoteAddr),
@@ -35,3 +47,71 @@ func RequestLoggerMiddleware(logger log.ILogger) echo.MiddlewareFunc {
 		}
 	}
 }
+
+func isJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == echo.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// invalidBody is logged in place of a body that is not valid JSON, as it cannot be redacted.
+const invalidBody = "[INVALID JSON]"
+
+// redactBody hides the redacted fields of a JSON body. A body that is not valid JSON is never logged.
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return invalidBody
+	}
+
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return invalidBody
+	}
+	return string(redacted)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isRedactedBodyField(key) {
+				v[key] = entity.RedactedValue
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+		// A JSON Patch operation carries the value of the field its path, or from, points at.
+		if _, ok := v["value"]; ok && (isRedactedPointer(v["path"]) || isRedactedPointer(v["from"])) {
+			v["value"] = entity.RedactedValue
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// isRedactedPointer reports whether pointer is a JSON Pointer whose last token is a redacted field.
+func isRedactedPointer(pointer interface{}) bool {
+	p, ok := pointer.(string)
+	if !ok {
+		return false
+	}
+	token := p[strings.LastIndex(p, "/")+1:]
+	token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	return isRedactedBodyField(token)
+}
+
+func isRedactedBodyField(key string) bool {
+	for _, field := range redactedBodyFields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RequestLoggerSuite struct {
+	suite.Suite
+}
+
+func TestRequestLoggerSuite(t *testing.T) {
+	suite.Run(t, new(RequestLoggerSuite))
+}
+
+func (s *RequestLoggerSuite) TestRedactBody() {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"should log an empty body as empty", ``, ``},
+		{"should redact a password", `{"username":"a","password":"hunter2"}`, `{"password":"[REDACTED]","username":"a"}`},
+		{"should redact a nested password", `{"users":[{"Password":"hunter2"}]}`, `{"users":[{"Password":"[REDACTED]"}]}`},
+		{"should not log a truncated body", `{"username":"a","password":"hunter2"`, invalidBody},
+		{"should not log a body that is not JSON", `password=hunter2`, invalidBody},
+		{
+			"should redact the value of a patch of the password",
+			`[{"op":"replace","path":"/password","value":"hunter2"}]`,
+			`[{"op":"replace","path":"/password","value":"[REDACTED]"}]`,
+		},
+		{
+			"should redact the value of a patch of a nested password",
+			`[{"op":"test","path":"/credentials/password","value":"hunter2"}]`,
+			`[{"op":"test","path":"/credentials/password","value":"[REDACTED]"}]`,
+		},
+		{
+			"should redact a patch whose from is the password",
+			`[{"op":"copy","from":"/password","path":"/name","value":"hunter2"}]`,
+			`[{"from":"/password","op":"copy","path":"/name","value":"[REDACTED]"}]`,
+		},
+		{
+			"should keep the value of a patch of another field",
+			`[{"op":"replace","path":"/name","value":"John"}]`,
+			`[{"op":"replace","path":"/name","value":"John"}]`,
+		},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			got := redactBody([]byte(test.body))
+
+			s.Equal(test.want, got)
+			if test.body != "" {
+				s.NotContains(got, "hunter2")
+			}
+		})
+	}
+}
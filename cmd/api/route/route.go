@@ -13,21 +13,61 @@ import (
 	_ "github.com/wisesight/go-api-template/cmd/api/docs" // docs is generated by Swag CLI, you have to import it.
 )
 
-func NewRoute(config config.Config, app *echo.Echo, userHandler handler.IUser, probeHandler handler.IProbe) {
+// Handlers are the handlers NewRoute registers. Every one of them is required.
+type Handlers struct {
+	User  handler.IUser
+	Probe handler.IProbe
+	Job   handler.IJob
+	Auth  handler.IAuth
+}
+
+func NewRoute(config config.Config, app *echo.Echo, handlers Handlers) {
+	if handlers.User == nil || handlers.Probe == nil || handlers.Job == nil || handlers.Auth == nil {
+		panic("route: every handler is required")
+	}
+
 	app.GET("/", func(c echo.Context) error {
 
 		return c.String(http.StatusOK, "Hello world")
 	})
-	app.GET("/readyz", probeHandler.DBReadyCheck)
+	app.GET("/readyz", handlers.Probe.DBReadyCheck)
+	app.POST("/auth/login", handlers.Auth.Login)
 
 	u := app.Group("/user")
 
 	u.Use(middleware.NewVerifyJWTAuth([]byte(config.JWTSecret), config.JWTSigningMethod))
 	u.Use(middleware.ExtractJWTClaims)
 
-	u.GET("", userHandler.GetAll)
-	u.GET("/", userHandler.GetUser)
-	u.POST("/", userHandler.Create)
+	u.GET("", handlers.User.GetAll)
+	u.GET("/", handlers.User.GetUser)
+	u.POST("/", handlers.User.Create)
+	// Aliases of the two routes above, whose trailing slash is easy to miss.
+	u.GET("/me", handlers.User.GetUser)
+	u.POST("", handlers.User.Create)
+	u.POST("\\:import", handlers.User.Import)
+	u.GET("\\:export", handlers.User.Export)
+	u.GET("/:id", handlers.User.Get)
+	u.PUT("/:id", handlers.User.Update)
+	u.PATCH("/:id", handlers.User.Patch)
+	u.DELETE("/:id", handlers.User.Delete)
+	u.GET("/:id/audit", handlers.User.GetAudit)
+
+	j := app.Group("/admin/jobs")
+
+	j.Use(middleware.NewVerifyJWTAuth([]byte(config.JWTSecret), config.JWTSigningMethod))
+	j.Use(middleware.ExtractJWTClaims)
+
+	j.GET("", handlers.Job.List)
+	j.DELETE("/completed", handlers.Job.PurgeCompleted)
+	j.GET("/:id", handlers.Job.Get)
+	j.POST("/:id/retry", handlers.Job.Retry)
+
+	a := app.Group("/admin/users")
+
+	a.Use(middleware.NewVerifyJWTAuth([]byte(config.JWTSecret), config.JWTSigningMethod))
+	a.Use(middleware.ExtractJWTClaims)
+
+	a.POST("/:id/restore", handlers.User.Restore)
 
 	app.GET("/swagger/*", echoSwagger.WrapHandler)
 }
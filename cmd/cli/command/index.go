@@ -0,0 +1,75 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+)
+
+type IIndex interface {
+	// Sync runs "index sync [-dry-run] [-drop]".
+	Sync(ctx context.Context, args []string) error
+}
+
+type index struct {
+	mongoDBAdapter adapter.IMongoDBAdapter
+	specs          []adapter.IndexSpec
+	out            io.Writer
+}
+
+func NewIndex(mongoDBAdapter adapter.IMongoDBAdapter, specs []adapter.IndexSpec, out io.Writer) IIndex {
+	return &index{
+		mongoDBAdapter: mongoDBAdapter,
+		specs:          specs,
+		out:            out,
+	}
+}
+
+func (c index) Sync(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("index sync", flag.ContinueOnError)
+	flags.SetOutput(c.out)
+	dryRun := flags.Bool("dry-run", false, "only report missing, changed and extra indexes")
+	drop := flags.Bool("drop", false, "drop extra indexes and rebuild changed ones")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	opts := adapter.IndexSyncOptions{DryRun: *dryRun, Drop: *drop}
+	for _, spec := range c.specs {
+		diff, err := c.mongoDBAdapter.SyncIndexes(ctx, spec, opts)
+		if err != nil {
+			return fmt.Errorf("sync indexes of %s: %w", spec.Collection.Name(), err)
+		}
+		c.print(diff, opts)
+	}
+
+	if opts.DryRun {
+		fmt.Fprintln(c.out, "dry run, no index was changed")
+	}
+
+	return nil
+}
+
+func (c index) print(diff adapter.IndexDiff, opts adapter.IndexSyncOptions) {
+	if diff.IsEmpty() {
+		fmt.Fprintf(c.out, "%s: in sync\n", diff.Collection)
+		return
+	}
+
+	fmt.Fprintf(c.out, "%s:\n", diff.Collection)
+	for _, index := range diff.Missing {
+		fmt.Fprintf(c.out, "  + %s\n", index.Name)
+	}
+	for _, index := range diff.Changed {
+		fmt.Fprintf(c.out, "  ~ %s\n", index.Name)
+	}
+	for _, name := range diff.Extra {
+		fmt.Fprintf(c.out, "  - %s\n", name)
+	}
+	if !opts.DryRun && !opts.Drop && (len(diff.Changed) > 0 || len(diff.Extra) > 0) {
+		fmt.Fprintln(c.out, "  changed and extra indexes were kept, rerun with -drop to replace them")
+	}
+}
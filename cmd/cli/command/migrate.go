@@ -0,0 +1,90 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/migration"
+)
+
+type IMigrate interface {
+	// Up runs "migrate up [-to version]".
+	Up(ctx context.Context, args []string) error
+	// Down runs "migrate down [-steps n]".
+	Down(ctx context.Context, args []string) error
+	// Status runs "migrate status".
+	Status(ctx context.Context, args []string) error
+}
+
+type migrate struct {
+	migrator migration.IMigrator
+	out      io.Writer
+}
+
+func NewMigrate(migrator migration.IMigrator, out io.Writer) IMigrate {
+	return &migrate{
+		migrator: migrator,
+		out:      out,
+	}
+}
+
+func (c migrate) Up(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+	flags.SetOutput(c.out)
+	target := flags.Int64("to", 0, "stop after this version (default all)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	applied, err := c.migrator.Up(ctx, *target)
+	for _, m := range applied {
+		fmt.Fprintf(c.out, "applied  %d  %s\n", m.Version, m.Description)
+	}
+	if err == nil && len(applied) == 0 {
+		fmt.Fprintln(c.out, "no pending migration")
+	}
+
+	return err
+}
+
+func (c migrate) Down(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+	flags.SetOutput(c.out)
+	steps := flags.Int("steps", 1, "number of migrations to revert")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	reverted, err := c.migrator.Down(ctx, *steps)
+	for _, m := range reverted {
+		fmt.Fprintf(c.out, "reverted %d  %s\n", m.Version, m.Description)
+	}
+	if err == nil && len(reverted) == 0 {
+		fmt.Fprintln(c.out, "no applied migration")
+	}
+
+	return err
+}
+
+func (c migrate) Status(ctx context.Context, args []string) error {
+	statuses, err := c.migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		state := "pending"
+		switch {
+		case status.Unknown:
+			state = "unknown, applied " + status.AppliedAt.Format(time.RFC3339)
+		case status.AppliedAt != nil:
+			state = "applied " + status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(c.out, "%d  %-40s  %s\n", status.Version, status.Description, state)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/wisesight/go-api-template/cmd/cli/command"
+	"github.com/wisesight/go-api-template/cmd/cli/migrations"
+	"github.com/wisesight/go-api-template/config"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/migration"
+	"github.com/wisesight/go-api-template/pkg/repository"
+)
+
+const usage = `usage: cli <command> [flags]
+
+commands:
+  index sync [-dry-run] [-drop]  create declared indexes that are missing
+  migrate up [-to version]       apply pending migrations
+  migrate down [-steps n]        revert the latest migrations
+  migrate status                 list migrations and whether they are applied
+`
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg := config.NewConfig()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	mongodbClient, err := adapter.NewMongoDBConnection(ctx, cfg.MongoDBURI)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		if err = mongodbClient.Disconnect(context.Background()); err != nil {
+			panic(err)
+		}
+	}()
+
+	mongoDBAdapter := adapter.NewMongoDBAdapter(mongodbClient)
+	database := mongodbClient.Database(cfg.MongoDBDatabase)
+
+	indexSpecs := repository.Indexes(repository.IndexConfig{
+		UserCollection:          cfg.UserCollection,
+		JobCollection:           cfg.JobCollection,
+		JobDeadLetterCollection: cfg.JobDeadLetterCollection,
+		OutboxCollection:        cfg.OutboxCollection,
+		AuditCollection:         cfg.AuditCollection,
+	}, database)
+
+	logger, err := log.NewLoggerZap(&log.ZapConfig{})
+	if err != nil {
+		panic(err)
+	}
+
+	lock := adapter.NewMongoDBLock(adapter.LockConfig{}, mongoDBAdapter, database)
+
+	migrator, err := migration.NewMigrator(migration.MigratorConfig{}, mongoDBAdapter, database, lock, migrations.NewMigrations(cfg), logger)
+	if err != nil {
+		panic(err)
+	}
+
+	indexCommand := command.NewIndex(mongoDBAdapter, indexSpecs, os.Stdout)
+	migrateCommand := command.NewMigrate(migrator, os.Stdout)
+
+	switch os.Args[1] + " " + os.Args[2] {
+	case "index sync":
+		err = indexCommand.Sync(ctx, os.Args[3:])
+	case "migrate up":
+		err = migrateCommand.Up(ctx, os.Args[3:])
+	case "migrate down":
+		err = migrateCommand.Down(ctx, os.Args[3:])
+	case "migrate status":
+		err = migrateCommand.Status(ctx, os.Args[3:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/wisesight/go-api-template/config"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/migration"
+	"github.com/wisesight/go-api-template/pkg/password"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NewMigrations lists every migration of the application. Append new ones at the end.
+func NewMigrations(config config.Config) []migration.Migration {
+	return []migration.Migration{
+		{
+			Version:     202610180900,
+			Description: "rename users.birthdate to birth_date",
+			Up: func(ctx context.Context, mongoDBAdapter adapter.IMongoDBAdapter, database *mongo.Database) error {
+				return renameField(ctx, mongoDBAdapter, database.Collection(config.UserCollection), "birthdate", "birth_date")
+			},
+			Down: func(ctx context.Context, mongoDBAdapter adapter.IMongoDBAdapter, database *mongo.Database) error {
+				return renameField(ctx, mongoDBAdapter, database.Collection(config.UserCollection), "birth_date", "birthdate")
+			},
+		},
+		{
+			Version:     202610181500,
+			Description: "hash plaintext users.password",
+			Up: func(ctx context.Context, mongoDBAdapter adapter.IMongoDBAdapter, database *mongo.Database) error {
+				hasher, err := password.NewBcryptHasher(password.HasherConfig{
+					Cost: config.PasswordHashCost,
+				})
+				if err != nil {
+					return err
+				}
+				return hashPasswords(ctx, mongoDBAdapter, database.Collection(config.UserCollection), hasher)
+			},
+		},
+	}
+}
+
+func renameField(ctx context.Context, mongoDBAdapter adapter.IMongoDBAdapter, collection adapter.IMongoCollection, from string, to string) error {
+	_, err := mongoDBAdapter.UpdateMany(ctx, collection,
+		bson.D{{Key: from, Value: bson.D{{Key: "$exists", Value: true}}}},
+		bson.D{{Key: "$rename", Value: bson.D{{Key: from, Value: to}}}},
+	)
+	return err
+}
+
+// hashPasswordsBatchSize is how many hashed passwords are written at a time.
+const hashPasswordsBatchSize = 500
+
+// hashPasswords replaces every password that is not a hash yet with its hash. Hashing cannot be undone, so the
+// migration has no Down. Hashes are written in batches, so a failed run keeps the passwords it already hashed and
+// the next run only hashes the rest.
+func hashPasswords(ctx context.Context, mongoDBAdapter adapter.IMongoDBAdapter, collection adapter.IMongoCollection, hasher password.IHasher) error {
+	type user struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		Password string             `bson:"password"`
+	}
+
+	var models []mongo.WriteModel
+	err := mongoDBAdapter.FindEach(ctx, collection,
+		bson.D{{Key: "password", Value: bson.D{{Key: "$type", Value: "string"}}}},
+		func(decode adapter.DecodeFunc) error {
+			var u user
+			if err := decode(&u); err != nil {
+				return err
+			}
+			if password.IsHashed(u.Password) {
+				return nil
+			}
+
+			hash, err := hasher.Hash(u.Password)
+			if err != nil {
+				return err
+			}
+			// Matching the old value keeps a password changed meanwhile from being overwritten.
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.D{{Key: "_id", Value: u.ID}, {Key: "password", Value: u.Password}}).
+				SetUpdate(bson.D{{Key: "$set", Value: bson.D{{Key: "password", Value: hash}}}}))
+			if len(models) < hashPasswordsBatchSize {
+				return nil
+			}
+			_, err = mongoDBAdapter.BulkWrite(ctx, collection, models)
+			models = models[:0]
+			return err
+		},
+		options.Find().SetProjection(bson.D{{Key: "password", Value: 1}}),
+	)
+	if err != nil || len(models) == 0 {
+		return err
+	}
+
+	_, err = mongoDBAdapter.BulkWrite(ctx, collection, models)
+	return err
+}
@@ -0,0 +1,126 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// userAdapter serves users to FindEach and records every BulkWrite.
+type userAdapter struct {
+	adapter.IMongoDBAdapter
+	users   []bson.M
+	batches [][]mongo.WriteModel
+	// failBatch makes the BulkWrite with that 1-based number fail.
+	failBatch int
+}
+
+func (a *userAdapter) FindEach(ctx context.Context, collection adapter.IMongoCollection, filter interface{}, fn func(decode adapter.DecodeFunc) error, opts ...*options.FindOptions) error {
+	for _, user := range a.users {
+		raw, err := bson.Marshal(user)
+		if err != nil {
+			return err
+		}
+		if err := fn(func(v interface{}) error { return bson.Unmarshal(raw, v) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *userAdapter) BulkWrite(ctx context.Context, collection adapter.IMongoCollection, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	if len(a.batches)+1 == a.failBatch {
+		return nil, errors.New("write failed")
+	}
+	a.batches = append(a.batches, append([]mongo.WriteModel{}, models...))
+	return &mongo.BulkWriteResult{ModifiedCount: int64(len(models))}, nil
+}
+
+// prefixHasher stands in for bcrypt, which is too slow to hash thousands of passwords in a unit test.
+type prefixHasher struct{}
+
+func (prefixHasher) Hash(password string) (string, error) {
+	return "hash:" + password, nil
+}
+
+func (prefixHasher) Verify(hash string, password string) (bool, error) {
+	return hash == "hash:"+password, nil
+}
+
+func (prefixHasher) NeedsRehash(hash string) bool {
+	return !strings.HasPrefix(hash, "hash:")
+}
+
+type MigrationsSuite struct {
+	suite.Suite
+}
+
+func TestMigrationsSuite(t *testing.T) {
+	suite.Run(t, new(MigrationsSuite))
+}
+
+func (s *MigrationsSuite) users(n int) []bson.M {
+	users := make([]bson.M, n)
+	for i := range users {
+		users[i] = bson.M{"_id": primitive.NewObjectID(), "password": fmt.Sprintf("secret%d", i)}
+	}
+	return users
+}
+
+func (s *MigrationsSuite) TestHashPasswords() {
+	s.Run("should write the hashes in batches", func() {
+		mongoDBAdapter := &userAdapter{users: s.users(1001)}
+
+		err := hashPasswords(context.Background(), mongoDBAdapter, nil, prefixHasher{})
+
+		s.NoError(err)
+		s.Require().Len(mongoDBAdapter.batches, 3)
+		s.Len(mongoDBAdapter.batches[0], 500)
+		s.Len(mongoDBAdapter.batches[1], 500)
+		s.Len(mongoDBAdapter.batches[2], 1)
+
+		model := mongoDBAdapter.batches[2][0].(*mongo.UpdateOneModel)
+		s.Equal(bson.D{{Key: "_id", Value: mongoDBAdapter.users[1000]["_id"]}, {Key: "password", Value: "secret1000"}}, model.Filter)
+		s.Equal(bson.D{{Key: "$set", Value: bson.D{{Key: "password", Value: "hash:secret1000"}}}}, model.Update)
+	})
+
+	s.Run("should skip passwords that are already hashed", func() {
+		users := s.users(2)
+		users[0]["password"] = "$2a$12$R9h/cIPz0gi.URNNX3kh2OPST9/PgBkqquzi.Ss7KIUgO2t0jWMUW"
+		mongoDBAdapter := &userAdapter{users: users}
+
+		err := hashPasswords(context.Background(), mongoDBAdapter, nil, prefixHasher{})
+
+		s.NoError(err)
+		s.Require().Len(mongoDBAdapter.batches, 1)
+		s.Len(mongoDBAdapter.batches[0], 1)
+	})
+
+	s.Run("should keep the batches written before a failed one", func() {
+		mongoDBAdapter := &userAdapter{users: s.users(1200), failBatch: 2}
+
+		err := hashPasswords(context.Background(), mongoDBAdapter, nil, prefixHasher{})
+
+		s.EqualError(err, "write failed")
+		s.Require().Len(mongoDBAdapter.batches, 1)
+		s.Len(mongoDBAdapter.batches[0], 500)
+	})
+
+	s.Run("should not write when every password is hashed", func() {
+		mongoDBAdapter := &userAdapter{}
+
+		err := hashPasswords(context.Background(), mongoDBAdapter, nil, prefixHasher{})
+
+		s.NoError(err)
+		s.Empty(mongoDBAdapter.batches)
+	})
+}
@@ -0,0 +1,65 @@
+// Package dependency builds the repositories and usecases shared by the commands, so every command wires them the
+// same way.
+package dependency
+
+import (
+	"time"
+
+	"github.com/wisesight/go-api-template/config"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/password"
+	"github.com/wisesight/go-api-template/pkg/repository"
+	"github.com/wisesight/go-api-template/pkg/usecase"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Repositories and the Usecases built from them are the dependency graph of the commands, from the database up.
+// Each layer is built in one place from the one below, so a usecase cannot be built without its repositories.
+type Repositories struct {
+	User   repository.IUser
+	Audit  repository.IAudit
+	Job    repository.IJob
+	Outbox adapter.IOutbox
+}
+
+type Usecases struct {
+	User       usecase.IUser
+	Credential usecase.ICredential
+	Job        usecase.IJob
+}
+
+// NewRepositories builds the repositories, each call bounded by timeout.
+func NewRepositories(cfg config.Config, timeout time.Duration, mongoDBAdapter adapter.IMongoDBAdapter, database *mongo.Database) Repositories {
+	outbox := adapter.NewMongoDBOutbox(adapter.OutboxConfig{
+		CollectionName: cfg.OutboxCollection,
+	}, mongoDBAdapter, database)
+
+	return Repositories{
+		User: repository.NewUser(repository.UserConfig{
+			Timeout: timeout,
+		}, mongoDBAdapter, database.Collection(cfg.UserCollection), outbox),
+		Audit: repository.NewAudit(repository.AuditConfig{
+			Timeout: timeout,
+		}, mongoDBAdapter, database.Collection(cfg.AuditCollection)),
+		Job: repository.NewJob(repository.JobConfig{
+			Timeout: timeout,
+		}, mongoDBAdapter, database.Collection(cfg.JobCollection), database.Collection(cfg.JobDeadLetterCollection)),
+		Outbox: outbox,
+	}
+}
+
+// NewUsecases fails when the configuration is invalid.
+func NewUsecases(cfg config.Config, mongoDBAdapter adapter.IMongoDBAdapter, repositories Repositories) (Usecases, error) {
+	hasher, err := password.NewBcryptHasher(password.HasherConfig{
+		Cost: cfg.PasswordHashCost,
+	})
+	if err != nil {
+		return Usecases{}, err
+	}
+
+	return Usecases{
+		User:       usecase.NewUser(repositories.User, repositories.Audit, repository.NewTransactor(mongoDBAdapter), hasher),
+		Credential: usecase.NewCredential(repositories.User, hasher),
+		Job:        usecase.NewJob(repositories.Job),
+	}, nil
+}
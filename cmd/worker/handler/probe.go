@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+type IProbe interface {
+	DBPing(ctx context.Context, job entity.Job) error
+}
+
+type probe struct {
+	mongoDBAdapter adapter.IMongoDBAdapter
+	logger         log.ILogger
+}
+
+func NewProbe(mongoDBAdapter adapter.IMongoDBAdapter, logger log.ILogger) IProbe {
+	return &probe{
+		mongoDBAdapter,
+		logger,
+	}
+}
+
+func (h probe) DBPing(ctx context.Context, job entity.Job) error {
+	if err := h.mongoDBAdapter.Ping(ctx, readpref.Primary()); err != nil {
+		return err
+	}
+	h.logger.Info(ctx, "database is reachable", log.String("jobID", job.ID.Hex()))
+	return nil
+}
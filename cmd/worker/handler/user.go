@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/usecase"
+	"github.com/wisesight/go-api-template/pkg/worker"
+)
+
+// IUser reacts to user events relayed from the outbox. A job's ID is the ID of the event that
+// produced it, so side effects can be made idempotent against redelivery.
+type IUser interface {
+	Created(ctx context.Context, job entity.Job) error
+	Updated(ctx context.Context, job entity.Job) error
+	// Changed is called for every change to the users collection, for work such as cache invalidation
+	// that must follow the stored data rather than domain events. user is nil for deletes.
+	Changed(ctx context.Context, event worker.ChangeEvent, user *entity.User) error
+	// PurgeDeleted removes users that were deleted longer ago than the retention window.
+	PurgeDeleted(ctx context.Context, job entity.Job) error
+}
+
+type UserConfig struct {
+	DeletedRetention time.Duration
+}
+
+type user struct {
+	userUseCase      usecase.IUser
+	deletedRetention time.Duration
+	logger           log.ILogger
+}
+
+func NewUser(userConfig UserConfig, userUseCase usecase.IUser, logger log.ILogger) IUser {
+	return &user{
+		userUseCase:      userUseCase,
+		deletedRetention: userConfig.DeletedRetention,
+		logger:           logger,
+	}
+}
+
+func (h user) Created(ctx context.Context, job entity.Job) error {
+	h.logger.Info(ctx, "user created",
+		log.String("eventID", job.ID.Hex()),
+		log.Any("userID", job.Payload["id"]),
+	)
+	return nil
+}
+
+func (h user) Updated(ctx context.Context, job entity.Job) error {
+	h.logger.Info(ctx, "user updated",
+		log.String("eventID", job.ID.Hex()),
+		log.Any("userID", job.Payload["id"]),
+	)
+	return nil
+}
+
+func (h user) Changed(ctx context.Context, event worker.ChangeEvent, user *entity.User) error {
+	userID, _ := event.DocumentID.ObjectIDOK()
+	h.logger.Info(ctx, "user changed",
+		log.String("operation", string(event.Operation)),
+		log.String("userID", userID.Hex()),
+	)
+	return nil
+}
+
+func (h user) PurgeDeleted(ctx context.Context, job entity.Job) error {
+	before := time.Now().Add(-h.deletedRetention)
+
+	deleted, err := h.userUseCase.PurgeDeleted(ctx, before)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info(ctx, "deleted users purged",
+		log.Int64("deleted", deleted),
+		log.String("before", before.Format(time.RFC3339)),
+	)
+	return nil
+}
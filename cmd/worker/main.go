@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/wisesight/go-api-template/cmd/internal/dependency"
+	"github.com/wisesight/go-api-template/cmd/worker/handler"
+	"github.com/wisesight/go-api-template/cmd/worker/route"
+	"github.com/wisesight/go-api-template/config"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/worker"
+)
+
+func main() {
+	cfg := config.NewConfig()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mongodbClient, err := adapter.NewMongoDBConnection(ctx, cfg.MongoDBURI)
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		disconnectCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err = mongodbClient.Disconnect(disconnectCtx); err != nil {
+			panic(err)
+		}
+	}()
+
+	logger, err := log.NewLoggerZap(&log.ZapConfig{Debug: true})
+	if err != nil {
+		panic(err)
+	}
+
+	mongoDBAdapter := adapter.NewMongoDBAdapter(mongodbClient)
+	database := mongodbClient.Database(cfg.MongoDBDatabase)
+
+	queue := adapter.NewMongoDBQueue(adapter.QueueConfig{
+		CollectionName:           cfg.JobCollection,
+		DeadLetterCollectionName: cfg.JobDeadLetterCollection,
+		VisibilityTimeout:        cfg.JobVisibilityTimeout,
+	}, mongoDBAdapter, database)
+
+	w := worker.NewWorker(worker.WorkerConfig{
+		Concurrency:       cfg.WorkerConcurrency,
+		PollInterval:      cfg.WorkerPollInterval,
+		HeartbeatInterval: cfg.JobVisibilityTimeout / 3,
+	}, queue, logger)
+
+	lock := adapter.NewMongoDBLock(adapter.LockConfig{}, mongoDBAdapter, database)
+
+	scheduler := worker.NewScheduler(worker.SchedulerConfig{
+		TickInterval: cfg.SchedulerTickInterval,
+		LockTTL:      cfg.SchedulerLockTTL,
+	}, mongoDBAdapter, database, lock, queue, logger)
+
+	// Jobs such as purging deleted users touch many documents, so repository calls get longer than in the API.
+	repositories := dependency.NewRepositories(cfg, time.Minute, mongoDBAdapter, database)
+	usecases, err := dependency.NewUsecases(cfg, mongoDBAdapter, repositories)
+	if err != nil {
+		panic(err)
+	}
+
+	relay := worker.NewRelay(worker.RelayConfig{
+		PollInterval: cfg.OutboxPollInterval,
+	}, repositories.Outbox, queue, logger)
+
+	userSubscriber := worker.NewSubscriber(worker.SubscriberConfig{
+		Name:    "users",
+		LockTTL: cfg.SchedulerLockTTL,
+	}, mongoDBAdapter, database, database.Collection(cfg.UserCollection), lock, logger)
+
+	probeHandler := handler.NewProbe(mongoDBAdapter, logger)
+	userHandler := handler.NewUser(handler.UserConfig{
+		DeletedRetention: cfg.UserDeletedRetention,
+	}, usecases.User, logger)
+
+	route.NewRoute(cfg, w, scheduler, userSubscriber, probeHandler, userHandler)
+
+	go func() {
+		if err := w.Run(context.Background()); err != nil {
+			logger.Fatal(context.Background(), "worker stopped", log.Error(err))
+		}
+	}()
+
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		scheduler.Run(schedulerCtx)
+	}()
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(relayCtx)
+	}()
+
+	subscriberCtx, stopSubscriber := context.WithCancel(context.Background())
+	subscriberDone := make(chan struct{})
+	go func() {
+		defer close(subscriberDone)
+		userSubscriber.Run(subscriberCtx)
+	}()
+
+	logger.Info(context.Background(), "worker started", log.Int("concurrency", cfg.WorkerConcurrency))
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	stopScheduler()
+	stopRelay()
+	stopSubscriber()
+	<-schedulerDone
+	<-relayDone
+	<-subscriberDone
+
+	logger.Info(context.Background(), "draining in-flight jobs")
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.WorkerShutdownTimeout)
+	defer shutdownCancel()
+	if err := w.Shutdown(shutdownCtx); err != nil {
+		logger.Error(shutdownCtx, "worker did not drain in time", log.Error(err))
+	}
+}
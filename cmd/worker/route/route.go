@@ -0,0 +1,55 @@
+package route
+
+import (
+	"time"
+
+	"github.com/wisesight/go-api-template/cmd/worker/handler"
+	"github.com/wisesight/go-api-template/config"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/worker"
+)
+
+const (
+	JobTypeProbeDBPing      = "probe.db_ping"
+	JobTypeUserPurgeDeleted = "user.purge_deleted"
+)
+
+func NewRoute(
+	config config.Config,
+	w worker.IWorker,
+	scheduler worker.IScheduler,
+	userSubscriber worker.ISubscriber,
+	probeHandler handler.IProbe,
+	userHandler handler.IUser,
+) {
+	w.Register(JobTypeProbeDBPing, probeHandler.DBPing)
+	w.Register(entity.EventUserCreated, userHandler.Created)
+	w.Register(entity.EventUserUpdated, userHandler.Updated)
+	w.Register(JobTypeUserPurgeDeleted, userHandler.PurgeDeleted)
+
+	schedules := []worker.ScheduledJob{
+		{
+			Name:    "probe-db-ping",
+			Every:   time.Minute,
+			JobType: JobTypeProbeDBPing,
+			CatchUp: worker.CatchUpSkip,
+		},
+		{
+			Name:    "user-purge-deleted",
+			Cron:    "0 3 * * *",
+			JobType: JobTypeUserPurgeDeleted,
+			CatchUp: worker.CatchUpRunOnce,
+		},
+	}
+
+	for _, schedule := range schedules {
+		if err := scheduler.Add(schedule); err != nil {
+			panic(err)
+		}
+	}
+
+	userChanged := worker.TypedChangeHandler(userHandler.Changed)
+	for _, operation := range []worker.ChangeOperation{worker.ChangeInsert, worker.ChangeUpdate, worker.ChangeReplace, worker.ChangeDelete} {
+		userSubscriber.On(operation, userChanged)
+	}
+}
@@ -1,15 +1,34 @@
 package config
 
 import (
+	"time"
+
 	"github.com/caarlos0/env/v7"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port             int    `env:"PORT" envDefault:"8080"`
-	MongoDBURI       string `env:"MONGODB_URI"`
-	JWTSecret        string `env:"JWT_SECRET"`
-	JWTSigningMethod string `env:"JWT_SIGNING_METHOD"`
+	Port                    int           `env:"PORT" envDefault:"8080"`
+	MongoDBURI              string        `env:"MONGODB_URI"`
+	MongoDBDatabase         string        `env:"MONGODB_DATABASE" envDefault:"test"`
+	UserCollection          string        `env:"USER_COLLECTION" envDefault:"users"`
+	UserDeletedRetention    time.Duration `env:"USER_DELETED_RETENTION" envDefault:"720h"`
+	IndexSyncOnStartup      bool          `env:"INDEX_SYNC_ON_STARTUP" envDefault:"true"`
+	JWTSecret               string        `env:"JWT_SECRET"`
+	JWTSigningMethod        string        `env:"JWT_SIGNING_METHOD"`
+	JWTExpiration           time.Duration `env:"JWT_EXPIRATION" envDefault:"1h"`
+	PasswordHashCost        int           `env:"PASSWORD_HASH_COST" envDefault:"12"`
+	WorkerConcurrency       int           `env:"WORKER_CONCURRENCY" envDefault:"10"`
+	WorkerShutdownTimeout   time.Duration `env:"WORKER_SHUTDOWN_TIMEOUT" envDefault:"30s"`
+	WorkerPollInterval      time.Duration `env:"WORKER_POLL_INTERVAL" envDefault:"1s"`
+	JobCollection           string        `env:"JOB_COLLECTION" envDefault:"jobs"`
+	JobDeadLetterCollection string        `env:"JOB_DEAD_LETTER_COLLECTION" envDefault:"jobs_dead_letter"`
+	JobVisibilityTimeout    time.Duration `env:"JOB_VISIBILITY_TIMEOUT" envDefault:"30s"`
+	SchedulerTickInterval   time.Duration `env:"SCHEDULER_TICK_INTERVAL" envDefault:"5s"`
+	SchedulerLockTTL        time.Duration `env:"SCHEDULER_LOCK_TTL" envDefault:"30s"`
+	OutboxCollection        string        `env:"OUTBOX_COLLECTION" envDefault:"outbox"`
+	OutboxPollInterval      time.Duration `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`
+	AuditCollection         string        `env:"AUDIT_COLLECTION" envDefault:"audit_log"`
 }
 
 func NewConfig() Config {
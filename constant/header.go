@@ -0,0 +1,6 @@
+package constant
+
+const (
+	HEADER_ETAG     = "ETag"
+	HEADER_IF_MATCH = "If-Match"
+)
@@ -0,0 +1,203 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IndexKey struct {
+	Field      string
+	Descending bool
+}
+
+// Index declares an index. Name identifies it when comparing against the live collection.
+type Index struct {
+	Name   string
+	Keys   []IndexKey
+	Unique bool
+	// ExpireAfter makes a TTL index. The single key must hold dates.
+	ExpireAfter time.Duration
+	// PartialFilter limits the index to documents that match it.
+	PartialFilter bson.D
+}
+
+// IndexSpec declares the indexes one collection should have, besides _id.
+type IndexSpec struct {
+	Collection IMongoCollection
+	Indexes    []Index
+}
+
+type IndexSyncOptions struct {
+	// DryRun only reports the diff.
+	DryRun bool
+	// Drop removes extra indexes and rebuilds changed ones. Without it they are only reported.
+	Drop bool
+}
+
+type IndexDiff struct {
+	Collection string
+	Missing    []Index
+	Changed    []Index
+	// Extra names live indexes that are not declared.
+	Extra []string
+}
+
+func (d IndexDiff) IsEmpty() bool {
+	return len(d.Missing) == 0 && len(d.Changed) == 0 && len(d.Extra) == 0
+}
+
+type liveIndex struct {
+	Name                    string   `bson:"name"`
+	Key                     bson.D   `bson:"key"`
+	Unique                  bool     `bson:"unique"`
+	ExpireAfterSeconds      *int64   `bson:"expireAfterSeconds"`
+	PartialFilterExpression bson.Raw `bson:"partialFilterExpression"`
+}
+
+func (*mongodb) SyncIndexes(ctx context.Context, spec IndexSpec, opts IndexSyncOptions) (IndexDiff, error) {
+	diff := IndexDiff{Collection: spec.Collection.Name()}
+
+	cursor, err := spec.Collection.Indexes().List(ctx)
+	if err != nil {
+		return diff, err
+	}
+	var live []liveIndex
+	if err := cursor.All(ctx, &live); err != nil {
+		return diff, err
+	}
+
+	liveByName := map[string]liveIndex{}
+	for _, index := range live {
+		if index.Name != "_id_" {
+			liveByName[index.Name] = index
+		}
+	}
+
+	for _, index := range spec.Indexes {
+		existing, ok := liveByName[index.Name]
+		delete(liveByName, index.Name)
+
+		switch {
+		case !ok:
+			diff.Missing = append(diff.Missing, index)
+		case !sameIndex(index, existing):
+			diff.Changed = append(diff.Changed, index)
+		}
+	}
+	for name := range liveByName {
+		diff.Extra = append(diff.Extra, name)
+	}
+
+	if opts.DryRun {
+		return diff, nil
+	}
+
+	indexView := spec.Collection.Indexes()
+	create := diff.Missing
+	if opts.Drop {
+		for _, name := range diff.Extra {
+			if _, err := indexView.DropOne(ctx, name); err != nil {
+				return diff, err
+			}
+		}
+		for _, index := range diff.Changed {
+			if _, err := indexView.DropOne(ctx, index.Name); err != nil {
+				return diff, err
+			}
+		}
+		create = append(append([]Index{}, create...), diff.Changed...)
+	}
+	if len(create) == 0 {
+		return diff, nil
+	}
+
+	models := make([]mongo.IndexModel, len(create))
+	for i, index := range create {
+		models[i] = indexModel(index)
+	}
+	_, err = indexView.CreateMany(ctx, models)
+
+	return diff, err
+}
+
+func indexModel(index Index) mongo.IndexModel {
+	opts := options.Index().SetName(index.Name)
+	if index.Unique {
+		opts.SetUnique(true)
+	}
+	if index.ExpireAfter > 0 {
+		opts.SetExpireAfterSeconds(int32(index.ExpireAfter / time.Second))
+	}
+	if len(index.PartialFilter) > 0 {
+		opts.SetPartialFilterExpression(index.PartialFilter)
+	}
+
+	return mongo.IndexModel{Keys: indexKeys(index), Options: opts}
+}
+
+func indexKeys(index Index) bson.D {
+	keys := bson.D{}
+	for _, key := range index.Keys {
+		direction := int32(1)
+		if key.Descending {
+			direction = -1
+		}
+		keys = append(keys, bson.E{Key: key.Field, Value: direction})
+	}
+	return keys
+}
+
+func sameIndex(index Index, live liveIndex) bool {
+	if index.Unique != live.Unique {
+		return false
+	}
+
+	expireAfter := int64(-1)
+	if index.ExpireAfter > 0 {
+		expireAfter = int64(index.ExpireAfter / time.Second)
+	}
+	liveExpireAfter := int64(-1)
+	if live.ExpireAfterSeconds != nil {
+		liveExpireAfter = *live.ExpireAfterSeconds
+	}
+	if expireAfter != liveExpireAfter {
+		return false
+	}
+
+	if len(index.Keys) != len(live.Key) {
+		return false
+	}
+	for i, key := range index.Keys {
+		if live.Key[i].Key != key.Field || direction(live.Key[i].Value) != !key.Descending {
+			return false
+		}
+	}
+
+	var partialFilter []byte
+	if len(index.PartialFilter) > 0 {
+		var err error
+		if partialFilter, err = bson.Marshal(index.PartialFilter); err != nil {
+			return false
+		}
+	}
+	return bytes.Equal(partialFilter, live.PartialFilterExpression)
+}
+
+// direction reports whether a live index key is ascending. The server may return it as any numeric type.
+func direction(value interface{}) bool {
+	switch v := value.(type) {
+	case int32:
+		return v > 0
+	case int64:
+		return v > 0
+	case float64:
+		return v > 0
+	default:
+		return false
+	}
+}
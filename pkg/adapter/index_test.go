@@ -0,0 +1,71 @@
+package adapter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type IndexSuite struct {
+	suite.Suite
+}
+
+func TestIndexSuite(t *testing.T) {
+	suite.Run(t, new(IndexSuite))
+}
+
+func (s *IndexSuite) TestSameIndex() {
+	partialFilter := bson.D{{Key: "username", Value: bson.D{{Key: "$type", Value: "string"}}}}
+	rawPartialFilter, err := bson.Marshal(partialFilter)
+	s.Require().NoError(err)
+	expireAfter := int64(3600)
+
+	index := Index{
+		Name:          "username_unique",
+		Keys:          []IndexKey{{Field: "username"}, {Field: "created_at", Descending: true}},
+		Unique:        true,
+		ExpireAfter:   time.Hour,
+		PartialFilter: partialFilter,
+	}
+	live := liveIndex{
+		Name:                    "username_unique",
+		Key:                     bson.D{{Key: "username", Value: int32(1)}, {Key: "created_at", Value: float64(-1)}},
+		Unique:                  true,
+		ExpireAfterSeconds:      &expireAfter,
+		PartialFilterExpression: rawPartialFilter,
+	}
+
+	s.Run("should match a live index with the same definition", func() {
+		s.True(sameIndex(index, live))
+	})
+
+	s.Run("should detect a different key direction", func() {
+		changed := live
+		changed.Key = bson.D{{Key: "username", Value: int32(1)}, {Key: "created_at", Value: int32(1)}}
+
+		s.False(sameIndex(index, changed))
+	})
+
+	s.Run("should detect a dropped unique constraint", func() {
+		changed := live
+		changed.Unique = false
+
+		s.False(sameIndex(index, changed))
+	})
+
+	s.Run("should detect a different TTL", func() {
+		changed := live
+		changed.ExpireAfterSeconds = nil
+
+		s.False(sameIndex(index, changed))
+	})
+
+	s.Run("should detect a different partial filter", func() {
+		changed := live
+		changed.PartialFilterExpression = nil
+
+		s.False(sameIndex(index, changed))
+	})
+}
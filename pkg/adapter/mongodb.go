@@ -2,13 +2,24 @@ package adapter
 
 import (
 	"context"
+	"errors"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
+// ErrNoDocuments is returned by FindOne and FindOneAndUpdate when no document matches the filter.
+var ErrNoDocuments = mongo.ErrNoDocuments
+
+// ErrStopIteration can be returned from a FindEach or AggregateEach callback to stop early without an error.
+var ErrStopIteration = errors.New("stop iteration")
+
+// DecodeFunc decodes the current document into v.
+type DecodeFunc func(v interface{}) error
+
 func NewMongoDBConnection(ctx context.Context, uri string) (*mongo.Client, error) {
 	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
 	if err != nil {
@@ -20,13 +31,39 @@ func NewMongoDBConnection(ctx context.Context, uri string) (*mongo.Client, error
 type IMongoDBAdapter interface {
 	FindOne(ctx context.Context, collection IMongoCollection, result interface{}, filter interface{}, opts ...*options.FindOneOptions) error
 	Find(ctx context.Context, collection IMongoCollection, result interface{}, filter interface{}, opts ...*options.FindOptions) error
+	// FindEach calls fn for each matching document, holding only the current batch in memory.
+	FindEach(ctx context.Context, collection IMongoCollection, filter interface{}, fn func(decode DecodeFunc) error, opts ...*options.FindOptions) error
+	// FindPage decodes one page of documents into result, which must be a pointer to a slice.
+	FindPage(ctx context.Context, collection IMongoCollection, result interface{}, filter interface{}, pagination Pagination) (PageInfo, error)
+	// FindOneAndUpdate applies update to the first matching document and decodes it into result.
+	// Whether result holds the document before or after the update depends on opts.
+	FindOneAndUpdate(ctx context.Context, collection IMongoCollection, result interface{}, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) error
 	InsertOne(ctx context.Context, collection IMongoCollection, document interface{}, opts ...*options.InsertOneOptions) (*primitive.ObjectID, error)
 	InsertMany(ctx context.Context, collection IMongoCollection, documents []interface{}, opts ...*options.InsertManyOptions) ([]primitive.ObjectID, error)
+	// UpdateOne reports whether a document matched the filter or was upserted, even if the update left it unchanged.
+	// It does not report whether the update modified the document, so updating a document to the values it already
+	// holds is a success rather than a miss.
 	UpdateOne(ctx context.Context, collection IMongoCollection, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (bool, error)
 	UpdateMany(ctx context.Context, collection IMongoCollection, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (int64, error)
+	// ReplaceOne reports whether a document matched the filter or was upserted.
+	ReplaceOne(ctx context.Context, collection IMongoCollection, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (bool, error)
 	DeleteOne(ctx context.Context, collection IMongoCollection, filter interface{}, opts ...*options.DeleteOptions) (bool, error)
+	DeleteMany(ctx context.Context, collection IMongoCollection, filter interface{}, opts ...*options.DeleteOptions) (int64, error)
+	BulkWrite(ctx context.Context, collection IMongoCollection, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error)
+	CountDocuments(ctx context.Context, collection IMongoCollection, filter interface{}, opts ...*options.CountOptions) (int64, error)
+	Distinct(ctx context.Context, collection IMongoCollection, fieldName string, filter interface{}, opts ...*options.DistinctOptions) ([]interface{}, error)
 	Aggregate(ctx context.Context, collection IMongoCollection, result interface{}, pipeline interface{}, opts ...*options.AggregateOptions) error
+	// AggregateEach calls fn for each document the pipeline produces, holding only the current batch in memory.
+	AggregateEach(ctx context.Context, collection IMongoCollection, pipeline interface{}, fn func(decode DecodeFunc) error, opts ...*options.AggregateOptions) error
+	// Watch opens a change stream on the collection. The caller must close it.
+	Watch(ctx context.Context, collection IMongoCollection, pipeline interface{}, opts ...*options.ChangeStreamOptions) (*mongo.ChangeStream, error)
+	// SyncIndexes compares the declared indexes of a collection with the live ones and creates the missing ones.
+	SyncIndexes(ctx context.Context, spec IndexSpec, opts IndexSyncOptions) (IndexDiff, error)
 	Ping(ctx context.Context, rp *readpref.ReadPref) error
+	// WithTransaction runs fn in a transaction. Operations inside fn must use the ctx passed to fn to take part in it.
+	// Errors labelled TransientTransactionError rerun fn and UnknownTransactionCommitResult retries the commit, so fn
+	// must have no side effects outside the transaction. Called with the ctx of a running transaction, fn joins it.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error, opts ...*options.TransactionOptions) error
 }
 
 type mongodb struct{ mongoClient *mongo.Client }
@@ -59,6 +96,67 @@ func (*mongodb) Find(ctx context.Context, collection IMongoCollection, result in
 	return nil
 }
 
+func (*mongodb) FindEach(ctx context.Context, collection IMongoCollection, filter interface{}, fn func(decode DecodeFunc) error, opts ...*options.FindOptions) error {
+	cursor, err := collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return err
+	}
+	return each(ctx, cursor, fn)
+}
+
+func (*mongodb) FindPage(ctx context.Context, collection IMongoCollection, result interface{}, filter interface{}, pagination Pagination) (PageInfo, error) {
+	if pagination.Limit <= 0 {
+		pagination.Limit = defaultPageLimit
+	}
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	// One extra document tells whether another page follows.
+	opts := options.Find().
+		SetSort(sortDocument(pagination.Sort)).
+		SetLimit(pagination.Limit + 1)
+
+	if pagination.Cursor != "" {
+		cursor, err := decodePageCursor(pagination.Cursor, pagination.Sort)
+		if err != nil {
+			return PageInfo{}, err
+		}
+		filter = bson.D{{Key: "$and", Value: bson.A{filter, keysetFilter(pagination.Sort, cursor)}}}
+	} else if pagination.Offset > 0 {
+		opts.SetSkip(pagination.Offset)
+	}
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return PageInfo{}, err
+	}
+	var documents []bson.Raw
+	if err = cursor.All(ctx, &documents); err != nil {
+		return PageInfo{}, err
+	}
+
+	var pageInfo PageInfo
+	if int64(len(documents)) > pagination.Limit {
+		documents = documents[:pagination.Limit]
+		pageInfo.HasMore = true
+		pageInfo.NextCursor, err = encodePageCursor(pagination.Sort, documents[len(documents)-1])
+		if err != nil {
+			return PageInfo{}, err
+		}
+	}
+
+	return pageInfo, decodeAll(documents, result)
+}
+
+func (*mongodb) FindOneAndUpdate(ctx context.Context, collection IMongoCollection, result interface{}, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) error {
+	r := collection.FindOneAndUpdate(ctx, filter, update, opts...)
+	if r.Err() != nil {
+		return r.Err()
+	}
+	return r.Decode(result)
+}
+
 func (*mongodb) InsertOne(ctx context.Context, collection IMongoCollection, document interface{}, opts ...*options.InsertOneOptions) (*primitive.ObjectID, error) {
 	result, err := collection.InsertOne(ctx, document, opts...)
 	if err != nil {
@@ -71,7 +169,7 @@ func (*mongodb) InsertOne(ctx context.Context, collection IMongoCollection, docu
 func (*mongodb) InsertMany(ctx context.Context, collection IMongoCollection, documents []interface{}, opts ...*options.InsertManyOptions) ([]primitive.ObjectID, error) {
 	var insertedIds []primitive.ObjectID
 
-	result, err := collection.InsertMany(ctx, documents)
+	result, err := collection.InsertMany(ctx, documents, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +184,7 @@ func (*mongodb) UpdateOne(ctx context.Context, collection IMongoCollection, filt
 	if err != nil {
 		return false, err
 	}
-	return result.ModifiedCount > 0, nil
+	return result.MatchedCount > 0 || result.UpsertedCount > 0, nil
 }
 
 func (*mongodb) UpdateMany(ctx context.Context, collection IMongoCollection, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (int64, error) {
@@ -98,6 +196,14 @@ func (*mongodb) UpdateMany(ctx context.Context, collection IMongoCollection, fil
 
 }
 
+func (*mongodb) ReplaceOne(ctx context.Context, collection IMongoCollection, filter interface{}, replacement interface{}, opts ...*options.ReplaceOptions) (bool, error) {
+	result, err := collection.ReplaceOne(ctx, filter, replacement, opts...)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0 || result.UpsertedCount > 0, nil
+}
+
 func (*mongodb) DeleteOne(ctx context.Context, collection IMongoCollection, filter interface{}, opts ...*options.DeleteOptions) (bool, error) {
 	result, err := collection.DeleteOne(ctx, filter, opts...)
 	if err != nil {
@@ -107,6 +213,26 @@ func (*mongodb) DeleteOne(ctx context.Context, collection IMongoCollection, filt
 	return result.DeletedCount > 0, nil
 }
 
+func (*mongodb) DeleteMany(ctx context.Context, collection IMongoCollection, filter interface{}, opts ...*options.DeleteOptions) (int64, error) {
+	result, err := collection.DeleteMany(ctx, filter, opts...)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (*mongodb) BulkWrite(ctx context.Context, collection IMongoCollection, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	return collection.BulkWrite(ctx, models, opts...)
+}
+
+func (*mongodb) CountDocuments(ctx context.Context, collection IMongoCollection, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	return collection.CountDocuments(ctx, filter, opts...)
+}
+
+func (*mongodb) Distinct(ctx context.Context, collection IMongoCollection, fieldName string, filter interface{}, opts ...*options.DistinctOptions) ([]interface{}, error) {
+	return collection.Distinct(ctx, fieldName, filter, opts...)
+}
+
 func (*mongodb) Aggregate(ctx context.Context, collection IMongoCollection, result interface{}, pipeline interface{}, opts ...*options.AggregateOptions) error {
 	cursor, err := collection.Aggregate(ctx, pipeline, opts...)
 	if err != nil {
@@ -117,10 +243,54 @@ func (*mongodb) Aggregate(ctx context.Context, collection IMongoCollection, resu
 	}
 	return nil
 }
+func (*mongodb) AggregateEach(ctx context.Context, collection IMongoCollection, pipeline interface{}, fn func(decode DecodeFunc) error, opts ...*options.AggregateOptions) error {
+	cursor, err := collection.Aggregate(ctx, pipeline, opts...)
+	if err != nil {
+		return err
+	}
+	return each(ctx, cursor, fn)
+}
+
+// each calls fn for every document of cursor and closes it.
+func each(ctx context.Context, cursor *mongo.Cursor, fn func(decode DecodeFunc) error) error {
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		if err := fn(cursor.Decode); err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (*mongodb) Watch(ctx context.Context, collection IMongoCollection, pipeline interface{}, opts ...*options.ChangeStreamOptions) (*mongo.ChangeStream, error) {
+	return collection.Watch(ctx, pipeline, opts...)
+}
+
 func (adapter *mongodb) Ping(ctx context.Context, rp *readpref.ReadPref) error {
 	return adapter.mongoClient.Ping(ctx, nil)
 }
 
+func (adapter *mongodb) WithTransaction(ctx context.Context, fn func(ctx context.Context) error, opts ...*options.TransactionOptions) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := adapter.mongoClient.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	}, opts...)
+	return err
+}
+
 type IMongoCollection interface {
 	// Clone creates a copy of the Collection configured with the given CollectionOptions.
 	// The specified options are merged with the existing options on the collection, with the specified options taking
@@ -0,0 +1,353 @@
+//go:build integration
+
+package adapter_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoDBSuite runs its tests against MongoDB 5.0 in docker, as a single-node replica set for transactions. Each
+// test gets an empty database.
+type mongoDBSuite struct {
+	suite.Suite
+
+	pool     *dockertest.Pool
+	resource *dockertest.Resource
+
+	mongoClient *mongo.Client
+	database    *mongo.Database
+}
+
+func (s *mongoDBSuite) SetupSuite() {
+	var err error
+
+	s.pool, err = dockertest.NewPool("")
+
+	if err != nil {
+		s.FailNow("Could not connect to docker: %s", err)
+	}
+
+	if err = s.pool.Client.Ping(); err != nil {
+		s.FailNow("Could not ping docker: %s", err)
+	}
+
+	s.resource, err = s.pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "mongo",
+		Tag:        "5.0",
+		Cmd:        []string{"--replSet", "rs0"},
+	}, func(config *docker.HostConfig) {
+		// set AutoRemove to true so that stopped container goes away by itself
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{
+			Name: "no",
+		}
+	})
+
+	if err != nil {
+		s.FailNow("Could not start resource: %s", err)
+	}
+
+	err = s.pool.Retry(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var err error
+		s.mongoClient, err = mongo.Connect(
+			ctx,
+			options.Client().ApplyURI(
+				fmt.Sprintf("mongodb://localhost:%s/?directConnection=true", s.resource.GetPort("27017/tcp")),
+			),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		// initiate the replica set; code 23 means it is already initiated
+		err = s.mongoClient.Database("admin").RunCommand(ctx, bson.D{{Key: "replSetInitiate", Value: bson.D{}}}).Err()
+		var commandErr mongo.CommandError
+		if err != nil && !(errors.As(err, &commandErr) && commandErr.Code == 23) {
+			return err
+		}
+
+		var hello struct {
+			IsWritablePrimary bool `bson:"isWritablePrimary"`
+		}
+		if err := s.mongoClient.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+			return err
+		}
+		if !hello.IsWritablePrimary {
+			return errors.New("replica set has no primary yet")
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		s.FailNow("Could not connect to docker: %s", err)
+	}
+}
+
+func (s *mongoDBSuite) TearDownSuite() {
+	if err := s.pool.Purge(s.resource); err != nil {
+		s.FailNow("Could not purge resource: %s", err)
+	}
+
+	if err := s.mongoClient.Disconnect(context.Background()); err != nil {
+		s.FailNow("Could not disconnect mongo client: %s", err)
+	}
+}
+
+func (s *mongoDBSuite) SetupTest() {
+	s.database = s.mongoClient.Database("test")
+}
+
+func (s *mongoDBSuite) TearDownTest() {
+	s.database.Drop(context.Background())
+}
+
+type MongoDBSuite struct {
+	mongoDBSuite
+	mongoDBAdapter adapter.IMongoDBAdapter
+}
+
+func TestMongoDBSuite(t *testing.T) {
+	suite.Run(t, new(MongoDBSuite))
+}
+
+func (s *MongoDBSuite) SetupTest() {
+	s.mongoDBSuite.SetupTest()
+	s.mongoDBAdapter = adapter.NewMongoDBAdapter(s.mongoClient)
+}
+
+type item struct {
+	ID    string `bson:"_id"`
+	Group string `bson:"group"`
+	Count int    `bson:"count"`
+}
+
+// items empties the items collection and fills it with a, b and c, where a and b are in group x.
+func (s *MongoDBSuite) items() *mongo.Collection {
+	collection := s.database.Collection("items")
+	s.Require().NoError(collection.Drop(context.Background()))
+
+	_, err := collection.InsertMany(context.Background(), []interface{}{
+		item{ID: "a", Group: "x", Count: 1},
+		item{ID: "b", Group: "x", Count: 2},
+		item{ID: "c", Group: "y", Count: 3},
+	})
+	s.Require().NoError(err)
+
+	return collection
+}
+
+func (s *MongoDBSuite) find(collection *mongo.Collection, id string) item {
+	var result item
+	s.Require().NoError(collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&result))
+	return result
+}
+
+func (s *MongoDBSuite) TestFindOneAndUpdate() {
+	increment := bson.M{"$inc": bson.M{"count": 10}}
+
+	s.Run("should decode the document before the update by default", func() {
+		collection := s.items()
+		var result item
+
+		err := s.mongoDBAdapter.FindOneAndUpdate(context.Background(), collection, &result, bson.M{"_id": "a"}, increment)
+
+		s.NoError(err)
+		s.Equal(1, result.Count)
+		s.Equal(11, s.find(collection, "a").Count)
+	})
+
+	s.Run("should decode the document after the update when asked to", func() {
+		collection := s.items()
+		var result item
+
+		err := s.mongoDBAdapter.FindOneAndUpdate(context.Background(), collection, &result, bson.M{"_id": "a"}, increment,
+			options.FindOneAndUpdate().SetReturnDocument(options.After))
+
+		s.NoError(err)
+		s.Equal(11, result.Count)
+	})
+
+	s.Run("should return ErrNoDocuments when no document matches", func() {
+		collection := s.items()
+		var result item
+
+		err := s.mongoDBAdapter.FindOneAndUpdate(context.Background(), collection, &result, bson.M{"_id": "z"}, increment)
+
+		s.ErrorIs(err, adapter.ErrNoDocuments)
+	})
+}
+
+func (s *MongoDBSuite) TestUpdateOne() {
+	s.Run("should succeed when the update leaves the document unchanged", func() {
+		collection := s.items()
+
+		isSuccess, err := s.mongoDBAdapter.UpdateOne(context.Background(), collection, bson.M{"_id": "a"},
+			bson.M{"$set": bson.M{"count": 1}})
+
+		s.NoError(err)
+		s.True(isSuccess)
+	})
+
+	s.Run("should fail when no document matches", func() {
+		collection := s.items()
+
+		isSuccess, err := s.mongoDBAdapter.UpdateOne(context.Background(), collection, bson.M{"_id": "z"},
+			bson.M{"$set": bson.M{"count": 1}})
+
+		s.NoError(err)
+		s.False(isSuccess)
+	})
+
+	s.Run("should succeed when it upserts", func() {
+		collection := s.items()
+
+		isSuccess, err := s.mongoDBAdapter.UpdateOne(context.Background(), collection, bson.M{"_id": "z"},
+			bson.M{"$set": bson.M{"count": 1}}, options.Update().SetUpsert(true))
+
+		s.NoError(err)
+		s.True(isSuccess)
+		s.Equal(1, s.find(collection, "z").Count)
+	})
+}
+
+func (s *MongoDBSuite) TestReplaceOne() {
+	s.Run("should replace the matching document", func() {
+		collection := s.items()
+
+		isSuccess, err := s.mongoDBAdapter.ReplaceOne(context.Background(), collection, bson.M{"_id": "a"},
+			item{ID: "a", Group: "y", Count: 5})
+
+		s.NoError(err)
+		s.True(isSuccess)
+		s.Equal(item{ID: "a", Group: "y", Count: 5}, s.find(collection, "a"))
+	})
+
+	s.Run("should fail when no document matches", func() {
+		collection := s.items()
+
+		isSuccess, err := s.mongoDBAdapter.ReplaceOne(context.Background(), collection, bson.M{"_id": "z"},
+			item{ID: "z"})
+
+		s.NoError(err)
+		s.False(isSuccess)
+	})
+
+	s.Run("should succeed when it upserts", func() {
+		collection := s.items()
+
+		isSuccess, err := s.mongoDBAdapter.ReplaceOne(context.Background(), collection, bson.M{"_id": "z"},
+			item{ID: "z", Group: "z"}, options.Replace().SetUpsert(true))
+
+		s.NoError(err)
+		s.True(isSuccess)
+		s.Equal(item{ID: "z", Group: "z"}, s.find(collection, "z"))
+	})
+}
+
+func (s *MongoDBSuite) TestDeleteMany() {
+	collection := s.items()
+
+	deleted, err := s.mongoDBAdapter.DeleteMany(context.Background(), collection, bson.M{"group": "x"})
+
+	s.NoError(err)
+	s.Equal(int64(2), deleted)
+
+	count, err := collection.CountDocuments(context.Background(), bson.M{})
+	s.Require().NoError(err)
+	s.Equal(int64(1), count)
+}
+
+func (s *MongoDBSuite) TestBulkWrite() {
+	s.Run("should apply every model", func() {
+		collection := s.items()
+
+		result, err := s.mongoDBAdapter.BulkWrite(context.Background(), collection, []mongo.WriteModel{
+			mongo.NewInsertOneModel().SetDocument(item{ID: "d", Group: "y"}),
+			mongo.NewUpdateOneModel().SetFilter(bson.M{"_id": "a"}).SetUpdate(bson.M{"$inc": bson.M{"count": 1}}),
+			mongo.NewDeleteManyModel().SetFilter(bson.M{"group": "y"}),
+		})
+
+		s.NoError(err)
+		s.Equal(int64(1), result.InsertedCount)
+		s.Equal(int64(1), result.ModifiedCount)
+		s.Equal(int64(2), result.DeletedCount)
+		s.Equal(2, s.find(collection, "a").Count)
+	})
+
+	s.Run("should report the models that failed", func() {
+		collection := s.items()
+
+		_, err := s.mongoDBAdapter.BulkWrite(context.Background(), collection, []mongo.WriteModel{
+			mongo.NewInsertOneModel().SetDocument(item{ID: "a"}),
+			mongo.NewInsertOneModel().SetDocument(item{ID: "d"}),
+		}, options.BulkWrite().SetOrdered(false))
+
+		var bulkErr mongo.BulkWriteException
+		s.Require().ErrorAs(err, &bulkErr)
+		s.Require().Len(bulkErr.WriteErrors, 1)
+		s.Equal(0, bulkErr.WriteErrors[0].Index)
+		s.Equal("d", s.find(collection, "d").ID)
+	})
+}
+
+func (s *MongoDBSuite) TestCountDocuments() {
+	collection := s.items()
+
+	count, err := s.mongoDBAdapter.CountDocuments(context.Background(), collection, bson.M{"group": "x"})
+
+	s.NoError(err)
+	s.Equal(int64(2), count)
+}
+
+func (s *MongoDBSuite) TestDistinct() {
+	collection := s.items()
+
+	groups, err := s.mongoDBAdapter.Distinct(context.Background(), collection, "group", bson.M{})
+
+	s.NoError(err)
+	s.ElementsMatch([]interface{}{"x", "y"}, groups)
+}
+
+func (s *MongoDBSuite) TestWatch() {
+	collection := s.items()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	stream, err := s.mongoDBAdapter.Watch(ctx, collection, mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{"operationType": "insert"}}},
+	})
+	s.Require().NoError(err)
+	defer stream.Close(context.Background())
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": "a"}, bson.M{"$inc": bson.M{"count": 1}})
+	s.Require().NoError(err)
+	_, err = collection.InsertOne(ctx, item{ID: "d", Group: "z"})
+	s.Require().NoError(err)
+
+	s.Require().True(stream.Next(ctx), stream.Err())
+
+	var change struct {
+		OperationType string `bson:"operationType"`
+		FullDocument  item   `bson:"fullDocument"`
+	}
+	s.Require().NoError(stream.Decode(&change))
+	s.Equal("insert", change.OperationType)
+	s.Equal(item{ID: "d", Group: "z"}, change.FullDocument)
+}
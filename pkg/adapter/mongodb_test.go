@@ -0,0 +1,72 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type EachSuite struct {
+	suite.Suite
+}
+
+func TestEachSuite(t *testing.T) {
+	suite.Run(t, new(EachSuite))
+}
+
+func (s *EachSuite) cursor(names ...string) *mongo.Cursor {
+	documents := []interface{}{}
+	for _, name := range names {
+		documents = append(documents, bson.D{{Key: "name", Value: name}})
+	}
+	cursor, err := mongo.NewCursorFromDocuments(documents, nil, nil)
+	s.Require().NoError(err)
+	return cursor
+}
+
+type eachDocument struct {
+	Name string `bson:"name"`
+}
+
+func (s *EachSuite) TestEach() {
+
+	s.Run("should decode every document in order", func() {
+		names := []string{}
+
+		err := each(context.Background(), s.cursor("a", "b", "c"), func(decode DecodeFunc) error {
+			var document eachDocument
+			if err := decode(&document); err != nil {
+				return err
+			}
+			names = append(names, document.Name)
+			return nil
+		})
+
+		s.NoError(err)
+		s.Equal([]string{"a", "b", "c"}, names)
+	})
+
+	s.Run("should stop without error on ErrStopIteration", func() {
+		calls := 0
+
+		err := each(context.Background(), s.cursor("a", "b", "c"), func(decode DecodeFunc) error {
+			calls++
+			return ErrStopIteration
+		})
+
+		s.NoError(err)
+		s.Equal(1, calls)
+	})
+
+	s.Run("should return the callback error", func() {
+		err := each(context.Background(), s.cursor("a"), func(decode DecodeFunc) error {
+			return errors.New("callback failed")
+		})
+
+		s.EqualError(err, "callback failed")
+	})
+}
@@ -0,0 +1,75 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ILock is a distributed lease: at most one owner holds a named lock until the lease expires.
+type ILock interface {
+	// Acquire takes or renews the lock for owner. It returns false when another owner holds an unexpired lease.
+	Acquire(ctx context.Context, name string, owner string, ttl time.Duration) (bool, error)
+	// Release gives up the lock if owner still holds it.
+	Release(ctx context.Context, name string, owner string) error
+}
+
+type LockConfig struct {
+	CollectionName string
+}
+
+const defaultLockCollectionName = "locks"
+
+type mongodbLock struct {
+	mongoDBAdapter IMongoDBAdapter
+	collection     IMongoCollection
+}
+
+func NewMongoDBLock(lockConfig LockConfig, mongoDBAdapter IMongoDBAdapter, database *mongo.Database) ILock {
+	if lockConfig.CollectionName == "" {
+		lockConfig.CollectionName = defaultLockCollectionName
+	}
+
+	return &mongodbLock{
+		mongoDBAdapter: mongoDBAdapter,
+		collection:     database.Collection(lockConfig.CollectionName),
+	}
+}
+
+func (l *mongodbLock) Acquire(ctx context.Context, name string, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	filter := bson.D{
+		{Key: "_id", Value: name},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "owner", Value: owner}},
+			bson.D{{Key: "expires_at", Value: bson.D{{Key: "$lte", Value: now}}}},
+		}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "owner", Value: owner},
+		{Key: "expires_at", Value: now.Add(ttl)},
+	}}}
+
+	// When another owner holds the lease the filter misses, and the upsert collides on _id.
+	isSuccess, err := l.mongoDBAdapter.UpdateOne(ctx, l.collection, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return isSuccess, nil
+}
+
+func (l *mongodbLock) Release(ctx context.Context, name string, owner string) error {
+	_, err := l.mongoDBAdapter.DeleteOne(ctx, l.collection, bson.D{
+		{Key: "_id", Value: name},
+		{Key: "owner", Value: owner},
+	})
+	return err
+}
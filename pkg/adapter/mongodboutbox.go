@@ -0,0 +1,87 @@
+package adapter
+
+import (
+	"context"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IOutbox interface {
+	// Add records an event. Call it with the ctx of a transaction so the event commits with the write that caused it.
+	Add(ctx context.Context, event *entity.OutboxEvent) error
+	// Pending returns up to limit unpublished events, oldest first.
+	Pending(ctx context.Context, limit int64) ([]entity.OutboxEvent, error)
+	// MarkPublished records that an event has been handed over to the queue.
+	MarkPublished(ctx context.Context, id primitive.ObjectID) error
+}
+
+// OutboxIndexes find unpublished events and expire published ones after a week.
+// Events without published_at never expire.
+var OutboxIndexes = []Index{
+	{
+		Name:        "published_at_ttl",
+		Keys:        []IndexKey{{Field: "published_at"}},
+		ExpireAfter: 7 * 24 * time.Hour,
+	},
+}
+
+type OutboxConfig struct {
+	CollectionName string
+}
+
+const defaultOutboxCollectionName = "outbox"
+
+type mongodbOutbox struct {
+	mongoDBAdapter IMongoDBAdapter
+	collection     IMongoCollection
+}
+
+func NewMongoDBOutbox(outboxConfig OutboxConfig, mongoDBAdapter IMongoDBAdapter, database *mongo.Database) IOutbox {
+	if outboxConfig.CollectionName == "" {
+		outboxConfig.CollectionName = defaultOutboxCollectionName
+	}
+
+	return &mongodbOutbox{
+		mongoDBAdapter: mongoDBAdapter,
+		collection:     database.Collection(outboxConfig.CollectionName),
+	}
+}
+
+func (o *mongodbOutbox) Add(ctx context.Context, event *entity.OutboxEvent) error {
+	event.CreatedAt = time.Now()
+	event.PublishedAt = nil
+
+	id, err := o.mongoDBAdapter.InsertOne(ctx, o.collection, event)
+	if err != nil {
+		return err
+	}
+	event.ID = *id
+
+	return nil
+}
+
+func (o *mongodbOutbox) Pending(ctx context.Context, limit int64) ([]entity.OutboxEvent, error) {
+	events := []entity.OutboxEvent{}
+	err := o.mongoDBAdapter.Find(ctx, o.collection, &events,
+		bson.D{{Key: "published_at", Value: bson.D{{Key: "$exists", Value: false}}}},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (o *mongodbOutbox) MarkPublished(ctx context.Context, id primitive.ObjectID) error {
+	_, err := o.mongoDBAdapter.UpdateOne(ctx, o.collection,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "published_at", Value: time.Now()}}}},
+	)
+	return err
+}
@@ -0,0 +1,312 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrQueueEmpty is returned by Claim when no job is ready to run.
+	ErrQueueEmpty = errors.New("no job is ready to run")
+	// ErrLeaseLost is returned when a job's lease expired and another worker may have claimed it.
+	ErrLeaseLost = errors.New("job lease lost")
+	// ErrJobExists is returned by Enqueue when a job with the same ID is already stored.
+	ErrJobExists = errors.New("job already exists")
+)
+
+// JobErrorLeaseExpired is the code of the error recorded for a job whose last attempt ended without the worker
+// releasing its lease.
+const JobErrorLeaseExpired = "LEASE_EXPIRED"
+
+type IQueue interface {
+	// Enqueue stores a pending job. A zero RunAt means the job is ready immediately.
+	// Setting the job's ID makes the enqueue idempotent: a second job with that ID is rejected with ErrJobExists.
+	Enqueue(ctx context.Context, job *entity.Job) (*primitive.ObjectID, error)
+	// Claim atomically leases the next ready job of the types in maxAttempts to workerID.
+	// A running job whose lease has expired is ready to be claimed again, unless it already had the maximum
+	// attempts of its type: it is then moved to the dead-letter collection with a lease expired error, as whatever
+	// ran it crashed or hung. Every job gets at least one attempt.
+	Claim(ctx context.Context, workerID string, maxAttempts map[string]int) (*entity.Job, error)
+	// Heartbeat extends the lease of a claimed job by the visibility timeout.
+	Heartbeat(ctx context.Context, job *entity.Job) error
+	// Complete marks a claimed job as completed and releases its lease.
+	Complete(ctx context.Context, job *entity.Job) error
+	// Retry records a failed attempt and puts the job back to pending until runAt.
+	Retry(ctx context.Context, job *entity.Job, jobErr entity.JobError, runAt time.Time) error
+	// DeadLetter records a failed attempt and moves the job to the dead-letter collection.
+	DeadLetter(ctx context.Context, job *entity.Job, jobErr entity.JobError) error
+}
+
+// QueueIndexes serve Claim and purging of completed jobs.
+var QueueIndexes = []Index{
+	{
+		Name: "state_type_run_at",
+		Keys: []IndexKey{{Field: "state"}, {Field: "type"}, {Field: "run_at"}},
+	},
+	{
+		Name: "state_type_locked_until",
+		Keys: []IndexKey{{Field: "state"}, {Field: "type"}, {Field: "locked_until"}},
+	},
+	{
+		Name: "state_completed_at",
+		Keys: []IndexKey{{Field: "state"}, {Field: "completed_at"}},
+	},
+}
+
+// DeadLetterIndexes serve listing dead jobs by type.
+var DeadLetterIndexes = []Index{
+	{
+		Name: "type_created_at",
+		Keys: []IndexKey{{Field: "type"}, {Field: "created_at", Descending: true}},
+	},
+}
+
+type QueueConfig struct {
+	CollectionName           string
+	DeadLetterCollectionName string
+	VisibilityTimeout        time.Duration
+}
+
+const (
+	defaultQueueCollectionName           = "jobs"
+	defaultQueueDeadLetterCollectionName = "jobs_dead_letter"
+	defaultQueueVisibilityTimeout        = 30 * time.Second
+)
+
+type mongodbQueue struct {
+	mongoDBAdapter       IMongoDBAdapter
+	collection           IMongoCollection
+	deadLetterCollection IMongoCollection
+	visibilityTimeout    time.Duration
+}
+
+func NewMongoDBQueue(queueConfig QueueConfig, mongoDBAdapter IMongoDBAdapter, database *mongo.Database) IQueue {
+	if queueConfig.CollectionName == "" {
+		queueConfig.CollectionName = defaultQueueCollectionName
+	}
+	if queueConfig.DeadLetterCollectionName == "" {
+		queueConfig.DeadLetterCollectionName = defaultQueueDeadLetterCollectionName
+	}
+	if queueConfig.VisibilityTimeout <= 0 {
+		queueConfig.VisibilityTimeout = defaultQueueVisibilityTimeout
+	}
+
+	return &mongodbQueue{
+		mongoDBAdapter:       mongoDBAdapter,
+		collection:           database.Collection(queueConfig.CollectionName),
+		deadLetterCollection: database.Collection(queueConfig.DeadLetterCollectionName),
+		visibilityTimeout:    queueConfig.VisibilityTimeout,
+	}
+}
+
+func (q *mongodbQueue) Enqueue(ctx context.Context, job *entity.Job) (*primitive.ObjectID, error) {
+	now := time.Now()
+
+	job.State = entity.JobStatePending
+	job.Attempts = 0
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+
+	id, err := q.mongoDBAdapter.InsertOne(ctx, q.collection, job)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrJobExists
+		}
+		return nil, err
+	}
+	job.ID = *id
+
+	return id, nil
+}
+
+func (q *mongodbQueue) Claim(ctx context.Context, workerID string, maxAttempts map[string]int) (*entity.Job, error) {
+	now := time.Now()
+	lockedUntil := now.Add(q.visibilityTimeout)
+
+	jobTypes := make([]string, 0, len(maxAttempts))
+	exhausted := bson.A{}
+	for jobType, max := range maxAttempts {
+		if max < 1 {
+			max = 1
+		}
+		jobTypes = append(jobTypes, jobType)
+		exhausted = append(exhausted, bson.D{
+			{Key: "type", Value: jobType},
+			{Key: "attempts", Value: bson.D{{Key: "$gte", Value: max}}},
+		})
+	}
+
+	leaseExpired := bson.D{
+		{Key: "state", Value: entity.JobStateRunning},
+		{Key: "locked_until", Value: bson.D{{Key: "$lte", Value: now}}},
+	}
+	if len(exhausted) > 0 {
+		if err := q.deadLetterExpired(ctx, append(leaseExpired, bson.E{Key: "$or", Value: exhausted})); err != nil {
+			return nil, err
+		}
+		leaseExpired = append(leaseExpired, bson.E{Key: "$nor", Value: exhausted})
+	}
+
+	filter := bson.D{
+		{Key: "type", Value: bson.D{{Key: "$in", Value: jobTypes}}},
+		{Key: "$or", Value: bson.A{
+			bson.D{
+				{Key: "state", Value: entity.JobStatePending},
+				{Key: "run_at", Value: bson.D{{Key: "$lte", Value: now}}},
+			},
+			leaseExpired,
+		}},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "state", Value: entity.JobStateRunning},
+			{Key: "locked_by", Value: workerID},
+			{Key: "locked_until", Value: lockedUntil},
+			{Key: "updated_at", Value: now},
+		}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job entity.Job
+	err := q.mongoDBAdapter.FindOneAndUpdate(ctx, q.collection, &job, filter, update, opts)
+	if err != nil {
+		if errors.Is(err, ErrNoDocuments) {
+			return nil, ErrQueueEmpty
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// deadLetterExpired moves one job matching filter, a running job whose lease expired, to the dead-letter collection.
+// Claim calls it every time, so every such job is moved eventually.
+func (q *mongodbQueue) deadLetterExpired(ctx context.Context, filter bson.D) error {
+	var job entity.Job
+	err := q.mongoDBAdapter.FindOne(ctx, q.collection, &job, filter)
+	if errors.Is(err, ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = q.DeadLetter(ctx, &job, entity.JobError{
+		Attempt:  job.Attempts,
+		Message:  "job lease expired",
+		Code:     JobErrorLeaseExpired,
+		FailedAt: time.Now(),
+	})
+	if errors.Is(err, ErrLeaseLost) {
+		// Another worker moved it first.
+		return nil
+	}
+	return err
+}
+
+func (q *mongodbQueue) Heartbeat(ctx context.Context, job *entity.Job) error {
+	lockedUntil := time.Now().Add(q.visibilityTimeout)
+
+	isSuccess, err := q.mongoDBAdapter.UpdateOne(ctx, q.collection, q.leaseFilter(job), bson.D{
+		{Key: "$set", Value: bson.D{{Key: "locked_until", Value: lockedUntil}}},
+	})
+	if err != nil {
+		return err
+	}
+	if !isSuccess {
+		return ErrLeaseLost
+	}
+	job.LockedUntil = &lockedUntil
+
+	return nil
+}
+
+func (q *mongodbQueue) Complete(ctx context.Context, job *entity.Job) error {
+	now := time.Now()
+
+	return q.release(ctx, job, bson.D{
+		{Key: "state", Value: entity.JobStateCompleted},
+		{Key: "completed_at", Value: now},
+		{Key: "updated_at", Value: now},
+	})
+}
+
+func (q *mongodbQueue) Retry(ctx context.Context, job *entity.Job, jobErr entity.JobError, runAt time.Time) error {
+	return q.release(ctx, job, bson.D{
+		{Key: "state", Value: entity.JobStatePending},
+		{Key: "run_at", Value: runAt},
+		{Key: "updated_at", Value: time.Now()},
+	}, bson.E{Key: "$push", Value: bson.D{{Key: "errors", Value: jobErr}}})
+}
+
+func (q *mongodbQueue) DeadLetter(ctx context.Context, job *entity.Job, jobErr entity.JobError) error {
+	now := time.Now()
+
+	dead := *job
+	dead.State = entity.JobStateDead
+	dead.LockedBy = ""
+	dead.LockedUntil = nil
+	dead.Errors = append(append([]entity.JobError{}, job.Errors...), jobErr)
+	dead.UpdatedAt = now
+	dead.DeadAt = &now
+
+	return q.mongoDBAdapter.WithTransaction(ctx, func(ctx context.Context) error {
+		isSuccess, err := q.mongoDBAdapter.DeleteOne(ctx, q.collection, q.leaseFilter(job))
+		if err != nil {
+			return err
+		}
+		if !isSuccess {
+			// Another worker owns the job now, so it is not dead yet.
+			return ErrLeaseLost
+		}
+
+		_, err = q.mongoDBAdapter.ReplaceOne(ctx, q.deadLetterCollection, bson.D{{Key: "_id", Value: job.ID}}, dead, options.Replace().SetUpsert(true))
+		return err
+	})
+}
+
+// release applies set, plus any extra update operators, to a job this worker still holds and drops its lease.
+func (q *mongodbQueue) release(ctx context.Context, job *entity.Job, set bson.D, extra ...bson.E) error {
+	update := bson.D{
+		{Key: "$set", Value: set},
+		{Key: "$unset", Value: bson.D{
+			{Key: "locked_by", Value: ""},
+			{Key: "locked_until", Value: ""},
+		}},
+	}
+	update = append(update, extra...)
+
+	isSuccess, err := q.mongoDBAdapter.UpdateOne(ctx, q.collection, q.leaseFilter(job), update)
+	if err != nil {
+		return err
+	}
+	if !isSuccess {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// leaseFilter matches job while the lease it was claimed with is still held. Every worker of a process shares
+// locked_by, so the attempt, which Claim increments, tells a re-claimed job from the stale one.
+func (q *mongodbQueue) leaseFilter(job *entity.Job) bson.D {
+	return bson.D{
+		{Key: "_id", Value: job.ID},
+		{Key: "state", Value: entity.JobStateRunning},
+		{Key: "locked_by", Value: job.LockedBy},
+		{Key: "attempts", Value: job.Attempts},
+	}
+}
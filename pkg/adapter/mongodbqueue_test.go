@@ -0,0 +1,102 @@
+//go:build integration
+
+package adapter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type QueueSuite struct {
+	mongoDBSuite
+}
+
+func TestQueueSuite(t *testing.T) {
+	suite.Run(t, new(QueueSuite))
+}
+
+func (s *QueueSuite) TestClaim() {
+	const visibilityTimeout = 100 * time.Millisecond
+
+	queue := adapter.NewMongoDBQueue(adapter.QueueConfig{VisibilityTimeout: visibilityTimeout},
+		adapter.NewMongoDBAdapter(s.mongoClient), s.database)
+	maxAttempts := map[string]int{"crash": 3}
+
+	s.Run("should dead-letter a job whose lease keeps expiring once it ran out of attempts", func() {
+		id, err := queue.Enqueue(context.Background(), &entity.Job{Type: "crash"})
+		s.Require().NoError(err)
+
+		// The worker holding the job crashes every time, so it never releases the lease.
+		for attempt := 1; attempt <= 3; attempt++ {
+			job, err := queue.Claim(context.Background(), "worker", maxAttempts)
+
+			s.Require().NoError(err)
+			s.Equal(*id, job.ID)
+			s.Equal(attempt, job.Attempts)
+
+			time.Sleep(2 * visibilityTimeout)
+		}
+
+		_, err = queue.Claim(context.Background(), "worker", maxAttempts)
+
+		s.ErrorIs(err, adapter.ErrQueueEmpty)
+
+		count, err := s.database.Collection("jobs").CountDocuments(context.Background(), bson.M{"_id": id})
+		s.NoError(err)
+		s.Zero(count)
+
+		var dead entity.Job
+		err = s.database.Collection("jobs_dead_letter").FindOne(context.Background(), bson.M{"_id": id}).Decode(&dead)
+		s.Require().NoError(err)
+		s.Equal(entity.JobStateDead, dead.State)
+		s.Require().Len(dead.Errors, 1)
+		s.Equal(3, dead.Errors[0].Attempt)
+		s.Equal(adapter.JobErrorLeaseExpired, dead.Errors[0].Code)
+	})
+
+	s.Run("should not claim a job whose lease is still held", func() {
+		_, err := queue.Enqueue(context.Background(), &entity.Job{Type: "crash"})
+		s.Require().NoError(err)
+
+		_, err = queue.Claim(context.Background(), "worker", maxAttempts)
+		s.Require().NoError(err)
+
+		_, err = queue.Claim(context.Background(), "other", maxAttempts)
+
+		s.ErrorIs(err, adapter.ErrQueueEmpty)
+	})
+}
+
+func (s *QueueSuite) TestComplete() {
+	const visibilityTimeout = 100 * time.Millisecond
+
+	queue := adapter.NewMongoDBQueue(adapter.QueueConfig{VisibilityTimeout: visibilityTimeout},
+		adapter.NewMongoDBAdapter(s.mongoClient), s.database)
+	maxAttempts := map[string]int{"slow": 3}
+
+	s.Run("should not complete a job re-claimed after its lease expired", func() {
+		_, err := queue.Enqueue(context.Background(), &entity.Job{Type: "slow"})
+		s.Require().NoError(err)
+
+		stale, err := queue.Claim(context.Background(), "worker", maxAttempts)
+		s.Require().NoError(err)
+
+		// A missed heartbeat lets another goroutine of the same process claim the job again.
+		time.Sleep(2 * visibilityTimeout)
+		current, err := queue.Claim(context.Background(), "worker", maxAttempts)
+		s.Require().NoError(err)
+		s.Require().Equal(stale.ID, current.ID)
+
+		s.ErrorIs(queue.Complete(context.Background(), stale), adapter.ErrLeaseLost)
+		s.ErrorIs(queue.Retry(context.Background(), stale, entity.JobError{Attempt: stale.Attempts}, time.Now()), adapter.ErrLeaseLost)
+		s.ErrorIs(queue.Heartbeat(context.Background(), stale), adapter.ErrLeaseLost)
+
+		s.NoError(queue.Complete(context.Background(), current))
+	})
+}
@@ -0,0 +1,154 @@
+package adapter
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// ErrInvalidCursor is returned by FindPage when a cursor is malformed or was issued for a different sort.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+const defaultPageLimit = 20
+
+type SortField struct {
+	// Field is a bson field name; dotted paths are allowed. It must not be _id, which is always the final
+	// tie-breaker, and it should exist on every document for cursors to be exact.
+	Field      string
+	Descending bool
+}
+
+type Pagination struct {
+	// Limit is the page size. Defaults to 20.
+	Limit int64
+	// Offset skips documents. It is ignored when Cursor is set.
+	Offset int64
+	// Cursor is a PageInfo.NextCursor from a previous page with the same Sort.
+	Cursor string
+	Sort   []SortField
+}
+
+type PageInfo struct {
+	// NextCursor fetches the page after this one. It is empty on the last page.
+	NextCursor string
+	HasMore    bool
+}
+
+// pageCursor is the position after the last document of a page: its sort values and _id.
+type pageCursor struct {
+	Sort   []string        `bson:"s"`
+	Values []bson.RawValue `bson:"v"`
+	ID     bson.RawValue   `bson:"i"`
+}
+
+func sortSpec(sort []SortField) []string {
+	spec := make([]string, len(sort))
+	for i, field := range sort {
+		spec[i] = field.Field
+		if field.Descending {
+			spec[i] = "-" + field.Field
+		}
+	}
+	return spec
+}
+
+func sortDocument(sort []SortField) bson.D {
+	document := bson.D{}
+	for _, field := range sort {
+		direction := 1
+		if field.Descending {
+			direction = -1
+		}
+		document = append(document, bson.E{Key: field.Field, Value: direction})
+	}
+	return append(document, bson.E{Key: "_id", Value: 1})
+}
+
+func encodePageCursor(sort []SortField, last bson.Raw) (string, error) {
+	cursor := pageCursor{Sort: sortSpec(sort)}
+	for _, field := range sort {
+		value, err := last.LookupErr(strings.Split(field.Field, ".")...)
+		if err != nil {
+			value = bson.RawValue{Type: bsontype.Null}
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+	id, err := last.LookupErr("_id")
+	if err != nil {
+		return "", err
+	}
+	cursor.ID = id
+
+	data, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodePageCursor(token string, sort []SortField) (pageCursor, error) {
+	var cursor pageCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := bson.Unmarshal(data, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if !reflect.DeepEqual(cursor.Sort, sortSpec(sort)) || len(cursor.Values) != len(sort) {
+		return cursor, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// keysetFilter matches the documents that sort after cursor: those greater on the first sort field,
+// or equal on it and greater on the next, and so on down to _id.
+func keysetFilter(sort []SortField, cursor pageCursor) bson.D {
+	or := bson.A{}
+	for i := 0; i <= len(sort); i++ {
+		clause := bson.D{}
+		for j := 0; j < i; j++ {
+			clause = append(clause, bson.E{Key: sort[j].Field, Value: cursor.Values[j]})
+		}
+
+		if i == len(sort) {
+			clause = append(clause, bson.E{Key: "_id", Value: bson.D{{Key: "$gt", Value: cursor.ID}}})
+		} else {
+			operator := "$gt"
+			if sort[i].Descending {
+				operator = "$lt"
+			}
+			clause = append(clause, bson.E{Key: sort[i].Field, Value: bson.D{{Key: operator, Value: cursor.Values[i]}}})
+		}
+
+		or = append(or, clause)
+	}
+	return bson.D{{Key: "$or", Value: or}}
+}
+
+// decodeAll decodes documents into result, which must be a pointer to a slice.
+func decodeAll(documents []bson.Raw, result interface{}) error {
+	resultValue := reflect.ValueOf(result)
+	if resultValue.Kind() != reflect.Ptr || resultValue.Elem().Kind() != reflect.Slice {
+		return errors.New("result must be a pointer to a slice")
+	}
+
+	sliceType := resultValue.Elem().Type()
+	slice := reflect.MakeSlice(sliceType, 0, len(documents))
+	for _, document := range documents {
+		element := reflect.New(sliceType.Elem())
+		if err := bson.Unmarshal(document, element.Interface()); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, element.Elem())
+	}
+	resultValue.Elem().Set(slice)
+
+	return nil
+}
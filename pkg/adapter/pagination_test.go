@@ -0,0 +1,108 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PaginationSuite struct {
+	suite.Suite
+}
+
+func TestPaginationSuite(t *testing.T) {
+	suite.Run(t, new(PaginationSuite))
+}
+
+func (s *PaginationSuite) TestPageCursor() {
+	sort := []SortField{{Field: "name"}, {Field: "profile.age", Descending: true}}
+	id := primitive.NewObjectID()
+	last, err := bson.Marshal(bson.D{
+		{Key: "_id", Value: id},
+		{Key: "name", Value: "john"},
+		{Key: "profile", Value: bson.D{{Key: "age", Value: 30}}},
+	})
+	s.Require().NoError(err)
+
+	token, err := encodePageCursor(sort, last)
+	s.Require().NoError(err)
+
+	s.Run("should round trip the sort values and _id", func() {
+		cursor, err := decodePageCursor(token, sort)
+
+		s.NoError(err)
+		s.Equal("john", cursor.Values[0].StringValue())
+		s.Equal(int32(30), cursor.Values[1].Int32())
+		s.Equal(id, cursor.ID.ObjectID())
+	})
+
+	s.Run("should reject a cursor issued for a different sort", func() {
+		_, err := decodePageCursor(token, []SortField{{Field: "name"}})
+
+		s.ErrorIs(err, ErrInvalidCursor)
+	})
+
+	s.Run("should reject a malformed cursor", func() {
+		_, err := decodePageCursor("not-a-cursor", sort)
+
+		s.ErrorIs(err, ErrInvalidCursor)
+	})
+}
+
+func (s *PaginationSuite) TestKeysetFilter() {
+
+	s.Run("should compare each sort field in its direction, then _id", func() {
+		sort := []SortField{{Field: "name"}, {Field: "age", Descending: true}}
+		cursor := pageCursor{
+			Values: []bson.RawValue{rawValue(s, "john"), rawValue(s, 30)},
+			ID:     rawValue(s, 7),
+		}
+
+		filter := keysetFilter(sort, cursor)
+
+		expected := bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "name", Value: bson.D{{Key: "$gt", Value: cursor.Values[0]}}}},
+			bson.D{
+				{Key: "name", Value: cursor.Values[0]},
+				{Key: "age", Value: bson.D{{Key: "$lt", Value: cursor.Values[1]}}},
+			},
+			bson.D{
+				{Key: "name", Value: cursor.Values[0]},
+				{Key: "age", Value: cursor.Values[1]},
+				{Key: "_id", Value: bson.D{{Key: "$gt", Value: cursor.ID}}},
+			},
+		}}}
+		s.Equal(expected, filter)
+	})
+}
+
+func (s *PaginationSuite) TestDecodeAll() {
+
+	s.Run("should decode every document into the slice", func() {
+		first, _ := bson.Marshal(bson.D{{Key: "name", Value: "a"}})
+		second, _ := bson.Marshal(bson.D{{Key: "name", Value: "b"}})
+		var result []struct {
+			Name string `bson:"name"`
+		}
+
+		err := decodeAll([]bson.Raw{first, second}, &result)
+
+		s.NoError(err)
+		s.Len(result, 2)
+		s.Equal("b", result[1].Name)
+	})
+
+	s.Run("should reject a result that is not a pointer to a slice", func() {
+		var result struct{}
+
+		s.Error(decodeAll(nil, &result))
+	})
+}
+
+func rawValue(s *PaginationSuite, value interface{}) bson.RawValue {
+	document, err := bson.Marshal(bson.D{{Key: "v", Value: value}})
+	s.Require().NoError(err)
+	return bson.Raw(document).Lookup("v")
+}
@@ -3,8 +3,18 @@ package apperror
 type AppErrorCode string
 
 const (
-	NotFound         AppErrorCode = "NOT_FOUND"
-	MySQLSyntaxError AppErrorCode = "MYSQL_SYNTAX_ERROR"
+	NotFound             AppErrorCode = "NOT_FOUND"
+	InvalidArgument      AppErrorCode = "INVALID_ARGUMENT"
+	InvalidID            AppErrorCode = "INVALID_ID"
+	DuplicateKey         AppErrorCode = "DUPLICATE_KEY"
+	Timeout              AppErrorCode = "TIMEOUT"
+	VersionConflict      AppErrorCode = "VERSION_CONFLICT"
+	Unauthenticated      AppErrorCode = "UNAUTHENTICATED"
+	MySQLSyntaxError     AppErrorCode = "MYSQL_SYNTAX_ERROR"
+	PreconditionRequired AppErrorCode = "PRECONDITION_REQUIRED"
+	PreconditionFailed   AppErrorCode = "PRECONDITION_FAILED"
+	UnsupportedFormat    AppErrorCode = "UNSUPPORTED_FORMAT"
+	Unprocessable        AppErrorCode = "UNPROCESSABLE"
 )
 
 type AppError struct {
@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/entity"
+)
+
+// Diff lists the fields that differ between before and after, which are structs of the same type or pointers to
+// them. A nil side stands for a struct that does not exist, so creating and deleting list every non-zero field, with
+// nil on the missing side. Fields are named after their bson tag, as stored, and skipped when tagged "-"; the values
+// of the redacted fields are replaced with entity.RedactedValue.
+func Diff(before, after interface{}, redact ...string) []entity.FieldChange {
+	beforeValue, afterValue := structValue(before), structValue(after)
+
+	var structType reflect.Type
+	switch {
+	case beforeValue.IsValid():
+		structType = beforeValue.Type()
+	case afterValue.IsValid():
+		structType = afterValue.Type()
+	default:
+		return nil
+	}
+
+	changes := []entity.FieldChange{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name := fieldName(field)
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+
+		beforeField, afterField := fieldValue(beforeValue, i), fieldValue(afterValue, i)
+		if equal(beforeField, afterField) {
+			continue
+		}
+
+		change := entity.FieldChange{
+			Field:  name,
+			Before: recorded(beforeValue, beforeField),
+			After:  recorded(afterValue, afterField),
+		}
+		if contains(redact, name) {
+			if change.Before != nil {
+				change.Before = entity.RedactedValue
+			}
+			if change.After != nil {
+				change.After = entity.RedactedValue
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+func structValue(v interface{}) reflect.Value {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return value
+}
+
+// fieldValue returns the field, dereferenced, or an invalid value when the struct or the pointer is missing.
+func fieldValue(value reflect.Value, i int) reflect.Value {
+	if !value.IsValid() {
+		return reflect.Value{}
+	}
+	field := value.Field(i)
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return reflect.Value{}
+		}
+		return field.Elem()
+	}
+	return field
+}
+
+// equal compares two fields, treating a missing field as the zero value.
+func equal(x, y reflect.Value) bool {
+	if !x.IsValid() && !y.IsValid() {
+		return true
+	}
+	if !x.IsValid() {
+		return y.IsZero()
+	}
+	if !y.IsValid() {
+		return x.IsZero()
+	}
+	if t, ok := x.Interface().(time.Time); ok {
+		return t.Equal(y.Interface().(time.Time))
+	}
+	return reflect.DeepEqual(x.Interface(), y.Interface())
+}
+
+// recorded is the value a change stores for a field: nil when the struct does not exist or the pointer is nil.
+func recorded(structValue, field reflect.Value) interface{} {
+	if !structValue.IsValid() || !field.IsValid() {
+		return nil
+	}
+	return field.Interface()
+}
+
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("bson"), ",")
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package audit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/audit"
+	"github.com/wisesight/go-api-template/pkg/entity"
+)
+
+type DiffSuite struct {
+	suite.Suite
+}
+
+func TestDiffSuite(t *testing.T) {
+	suite.Run(t, new(DiffSuite))
+}
+
+func (s *DiffSuite) TestDiff() {
+	birthDate := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.Run("should list only the changed fields", func() {
+		before := entity.User{ID: "1", Name: "user1", Username: "user1", BirthDate: birthDate}
+		after := entity.User{ID: "1", Name: "user2", Username: "user1", BirthDate: birthDate.In(time.Local)}
+
+		changes := audit.Diff(before, &after)
+
+		s.Equal([]entity.FieldChange{{Field: "name", Before: "user1", After: "user2"}}, changes)
+	})
+
+	s.Run("should list every set field when created", func() {
+		after := entity.User{ID: "1", Name: "user1"}
+
+		changes := audit.Diff(nil, after)
+
+		s.Equal([]entity.FieldChange{
+			{Field: "_id", Before: nil, After: "1"},
+			{Field: "name", Before: nil, After: "user1"},
+		}, changes)
+	})
+
+	s.Run("should record pointer fields by value", func() {
+		deletedAt := birthDate
+		before := entity.User{DeletedAt: &deletedAt}
+
+		changes := audit.Diff(before, entity.User{})
+
+		s.Equal([]entity.FieldChange{{Field: "deleted_at", Before: deletedAt, After: nil}}, changes)
+	})
+
+	s.Run("should redact the values of redacted fields", func() {
+		before := entity.User{Password: "old"}
+		after := entity.User{Password: "new"}
+
+		changes := audit.Diff(before, after, "password")
+
+		s.Equal([]entity.FieldChange{{Field: "password", Before: entity.RedactedValue, After: entity.RedactedValue}}, changes)
+	})
+
+	s.Run("should return nil when both sides are nil", func() {
+		s.Nil(audit.Diff(nil, (*entity.User)(nil)))
+	})
+}
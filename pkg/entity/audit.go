@@ -0,0 +1,35 @@
+package entity
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditActionCreate  AuditAction = "create"
+	AuditActionUpdate  AuditAction = "update"
+	AuditActionDelete  AuditAction = "delete"
+	AuditActionRestore AuditAction = "restore"
+)
+
+const AuditEntityUser = "user"
+
+// AuditRecord is one change to an entity, with who made it and which fields it changed.
+type AuditRecord struct {
+	ID         string        `bson:"_id,omitempty" json:"id"`
+	EntityType string        `bson:"entity_type" json:"entity_type" example:"user"`
+	EntityID   string        `bson:"entity_id" json:"entity_id" example:"63dccac268616ec85ccfcfd2"`
+	Action     AuditAction   `bson:"action" json:"action" example:"update"`
+	Actor      UserSession   `bson:"actor" json:"actor"`
+	RequestID  string        `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	Timestamp  time.Time     `bson:"timestamp" json:"timestamp"`
+	Changes    []FieldChange `bson:"changes" json:"changes"`
+}
+
+// FieldChange is the value of a field before and after a change. Redacted values are replaced with RedactedValue.
+type FieldChange struct {
+	Field  string      `bson:"field" json:"field" example:"name"`
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
+
+const RedactedValue = "[REDACTED]"
@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ChangeStreamToken is the resume token of the last change a subscriber handled.
+type ChangeStreamToken struct {
+	Name      string    `bson:"_id" json:"name"`
+	Token     bson.Raw  `bson:"token" json:"-"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
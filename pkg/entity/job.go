@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type JobState string
+
+const (
+	JobStatePending   JobState = "pending"
+	JobStateRunning   JobState = "running"
+	JobStateCompleted JobState = "completed"
+	// JobStateDead marks a job that exhausted its retries and was moved to the dead-letter collection.
+	JobStateDead JobState = "dead"
+)
+
+// JobError records why one attempt of a job failed.
+type JobError struct {
+	Attempt  int       `bson:"attempt" json:"attempt"`
+	Message  string    `bson:"message" json:"message"`
+	Code     string    `bson:"code,omitempty" json:"code,omitempty"`
+	FailedAt time.Time `bson:"failed_at" json:"failed_at"`
+}
+
+type Job struct {
+	ID          primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Type        string                 `bson:"type" json:"type"`
+	Payload     map[string]interface{} `bson:"payload,omitempty" json:"payload,omitempty"`
+	State       JobState               `bson:"state" json:"state"`
+	Attempts    int                    `bson:"attempts" json:"attempts"`
+	RunAt       time.Time              `bson:"run_at" json:"run_at"`
+	LockedBy    string                 `bson:"locked_by,omitempty" json:"locked_by,omitempty"`
+	LockedUntil *time.Time             `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	Errors      []JobError             `bson:"errors,omitempty" json:"errors,omitempty"`
+	CreatedAt   time.Time              `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time              `bson:"updated_at" json:"updated_at"`
+	CompletedAt *time.Time             `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	DeadAt      *time.Time             `bson:"dead_at,omitempty" json:"dead_at,omitempty"`
+}
+
+// JobSchedule is the persisted state of a recurring job, shared by all scheduler replicas.
+type JobSchedule struct {
+	Name      string     `bson:"_id" json:"name"`
+	NextRunAt time.Time  `bson:"next_run_at" json:"next_run_at"`
+	LastRunAt *time.Time `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// SchemaMigration records a migration that has been applied.
+type SchemaMigration struct {
+	Version     int64     `bson:"_id" json:"version"`
+	Description string    `bson:"description" json:"description"`
+	AppliedAt   time.Time `bson:"applied_at" json:"applied_at"`
+}
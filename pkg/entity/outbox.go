@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+)
+
+// OutboxEvent is a domain event recorded in the same transaction as the write that caused it.
+type OutboxEvent struct {
+	ID          primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Type        string                 `bson:"type" json:"type"`
+	AggregateID string                 `bson:"aggregate_id" json:"aggregate_id"`
+	Payload     map[string]interface{} `bson:"payload,omitempty" json:"payload,omitempty"`
+	CreatedAt   time.Time              `bson:"created_at" json:"created_at"`
+	PublishedAt *time.Time             `bson:"published_at,omitempty" json:"published_at,omitempty"`
+}
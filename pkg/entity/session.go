@@ -0,0 +1,16 @@
+package entity
+
+import "context"
+
+type userSessionKey struct{}
+
+// ContextWithUserSession returns a copy of ctx carrying the session of the user making the request.
+func ContextWithUserSession(ctx context.Context, session UserSession) context.Context {
+	return context.WithValue(ctx, userSessionKey{}, session)
+}
+
+// UserSessionFromContext returns the session stored by ContextWithUserSession, if any.
+func UserSessionFromContext(ctx context.Context) (UserSession, bool) {
+	session, ok := ctx.Value(userSessionKey{}).(UserSession)
+	return session, ok
+}
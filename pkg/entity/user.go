@@ -3,14 +3,18 @@ package entity
 import "time"
 
 type User struct {
-	ID        string    `example:"1234"`
-	Name      string    `example:"John Doe"`
-	Username  string    `example:"johndoe"`
-	Password  string    `example:"A1b2C3d$"`
-	BirthDate time.Time `json:"birth_date" example:"2006-01-02"`
+	ID       string `bson:"_id,omitempty" json:"id" example:"1234"`
+	Name     string `bson:"name" json:"name" example:"John Doe"`
+	Username string `bson:"username" json:"username" example:"johndoe"`
+	// Password is the password hash. It is never serialized to JSON.
+	Password  string    `bson:"password" json:"-"`
+	BirthDate time.Time `bson:"birth_date" json:"birth_date" example:"2006-01-02"`
+	// Version is incremented by every update.
+	Version   int64      `bson:"version" json:"version" example:"3"`
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
 
 type UserSession struct {
-	UserID   string `mapstructure:"user_id" json:"user_id"`
-	Username string `mapstructure:"username" json:"username"`
+	UserID   string `mapstructure:"user_id" bson:"user_id" json:"user_id"`
+	Username string `mapstructure:"username" bson:"username" json:"username"`
 }
@@ -0,0 +1,319 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrLocked is returned when another instance is applying migrations.
+var ErrLocked = errors.New("another instance is applying migrations")
+
+type MigrationFunc func(ctx context.Context, mongoDBAdapter adapter.IMongoDBAdapter, database *mongo.Database) error
+
+// Migration reshapes data from one schema version to the next. Versions only need to be unique and
+// increasing; a timestamp such as 202610181200 avoids collisions between branches.
+type Migration struct {
+	Version     int64
+	Description string
+	Up          MigrationFunc
+	// Down reverts Up. A migration without Down cannot be reverted.
+	Down MigrationFunc
+}
+
+type MigrationStatus struct {
+	Version     int64
+	Description string
+	// AppliedAt is nil for pending migrations.
+	AppliedAt *time.Time
+	// Unknown marks an applied version that is not registered, typically from a newer build.
+	Unknown bool
+}
+
+type IMigrator interface {
+	// Up applies pending migrations in version order, up to and including target. A zero target applies all.
+	Up(ctx context.Context, target int64) ([]Migration, error)
+	// Down reverts the latest steps applied migrations, newest first.
+	Down(ctx context.Context, steps int) ([]Migration, error)
+	Status(ctx context.Context) ([]MigrationStatus, error)
+}
+
+type MigratorConfig struct {
+	// ID identifies this instance in the migration lock. Defaults to hostname-pid.
+	ID             string
+	CollectionName string
+	LockTTL        time.Duration
+}
+
+const (
+	migrationLockName = "schema_migrations"
+
+	defaultCollectionName = "schema_migrations"
+	defaultLockTTL        = time.Minute
+)
+
+type migrator struct {
+	id             string
+	mongoDBAdapter adapter.IMongoDBAdapter
+	database       *mongo.Database
+	collection     adapter.IMongoCollection
+	lock           adapter.ILock
+	migrations     []Migration
+	lockTTL        time.Duration
+	logger         log.ILogger
+}
+
+func NewMigrator(
+	migratorConfig MigratorConfig,
+	mongoDBAdapter adapter.IMongoDBAdapter,
+	database *mongo.Database,
+	lock adapter.ILock,
+	migrations []Migration,
+	logger log.ILogger,
+) (IMigrator, error) {
+	if migratorConfig.ID == "" {
+		hostname, _ := os.Hostname()
+		migratorConfig.ID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if migratorConfig.CollectionName == "" {
+		migratorConfig.CollectionName = defaultCollectionName
+	}
+	if migratorConfig.LockTTL <= 0 {
+		migratorConfig.LockTTL = defaultLockTTL
+	}
+
+	sorted := append([]Migration{}, migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, migration := range sorted {
+		if migration.Version <= 0 || migration.Up == nil {
+			return nil, fmt.Errorf("migration %d needs a positive version and an Up function", migration.Version)
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("migration %d is registered twice", migration.Version)
+		}
+	}
+
+	return &migrator{
+		id:             migratorConfig.ID,
+		mongoDBAdapter: mongoDBAdapter,
+		database:       database,
+		collection:     database.Collection(migratorConfig.CollectionName),
+		lock:           lock,
+		migrations:     sorted,
+		lockTTL:        migratorConfig.LockTTL,
+		logger:         logger,
+	}, nil
+}
+
+func (m *migrator) Up(ctx context.Context, target int64) ([]Migration, error) {
+	var done []Migration
+
+	err := m.locked(ctx, func(ctx context.Context) error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range pendingUp(m.migrations, applied, target) {
+			m.logger.Info(ctx, "applying migration", log.Int64("version", migration.Version), log.String("description", migration.Description))
+
+			if err := migration.Up(ctx, m.mongoDBAdapter, m.database); err != nil {
+				return fmt.Errorf("migration %d up: %w", migration.Version, err)
+			}
+			_, err := m.mongoDBAdapter.InsertOne(ctx, m.collection, entity.SchemaMigration{
+				Version:     migration.Version,
+				Description: migration.Description,
+				AppliedAt:   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+func (m *migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+
+	err := m.locked(ctx, func(ctx context.Context) error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		migrations, err := pendingDown(m.migrations, applied, steps)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			m.logger.Info(ctx, "reverting migration", log.Int64("version", migration.Version), log.String("description", migration.Description))
+
+			if err := migration.Down(ctx, m.mongoDBAdapter, m.database); err != nil {
+				return fmt.Errorf("migration %d down: %w", migration.Version, err)
+			}
+			_, err := m.mongoDBAdapter.DeleteOne(ctx, m.collection, bson.D{{Key: "_id", Value: migration.Version}})
+			if err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+func (m *migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	records := []entity.SchemaMigration{}
+	if err := m.mongoDBAdapter.Find(ctx, m.collection, &records, bson.D{}); err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]entity.SchemaMigration{}
+	for _, record := range records {
+		byVersion[record.Version] = record
+	}
+
+	statuses := []MigrationStatus{}
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if record, ok := byVersion[migration.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+			delete(byVersion, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range byVersion {
+		appliedAt := record.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Version:     record.Version,
+			Description: record.Description,
+			AppliedAt:   &appliedAt,
+			Unknown:     true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses, nil
+}
+
+func (m *migrator) applied(ctx context.Context) (map[int64]bool, error) {
+	records := []entity.SchemaMigration{}
+	err := m.mongoDBAdapter.Find(ctx, m.collection, &records, bson.D{}, options.Find().SetProjection(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[int64]bool{}
+	for _, record := range records {
+		applied[record.Version] = true
+	}
+	return applied, nil
+}
+
+// locked runs fn while holding the migration lock, renewing it until fn returns.
+// fn's ctx is cancelled if the lock is lost.
+func (m *migrator) locked(ctx context.Context, fn func(ctx context.Context) error) error {
+	isLocked, err := m.lock.Acquire(ctx, migrationLockName, m.id, m.lockTTL)
+	if err != nil {
+		return err
+	}
+	if !isLocked {
+		return ErrLocked
+	}
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := m.lock.Release(releaseCtx, migrationLockName, m.id); err != nil {
+			m.logger.Warn(releaseCtx, "failed to release migration lock", log.Error(err))
+		}
+	}()
+
+	lockedCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		ticker := time.NewTicker(m.lockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-lockedCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			isLocked, err := m.lock.Acquire(lockedCtx, migrationLockName, m.id, m.lockTTL)
+			if err != nil || !isLocked {
+				m.logger.Error(lockedCtx, "lost migration lock", log.Error(err))
+				cancel()
+				return
+			}
+		}
+	}()
+
+	err = fn(lockedCtx)
+	cancel()
+	<-renewed
+
+	return err
+}
+
+// pendingUp lists the registered migrations that are not applied, up to target, in version order.
+func pendingUp(migrations []Migration, applied map[int64]bool, target int64) []Migration {
+	pending := []Migration{}
+	for _, migration := range migrations {
+		if target > 0 && migration.Version > target {
+			break
+		}
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// pendingDown lists the latest steps applied migrations, newest first. It refuses when an applied
+// migration is not registered, since reverting older migrations under it could break it.
+func pendingDown(migrations []Migration, applied map[int64]bool, steps int) ([]Migration, error) {
+	registered := map[int64]bool{}
+	for _, migration := range migrations {
+		registered[migration.Version] = true
+	}
+	for version := range applied {
+		if !registered[version] {
+			return nil, fmt.Errorf("migration %d is applied but not registered in this build", version)
+		}
+	}
+
+	pending := []Migration{}
+	for i := len(migrations) - 1; i >= 0 && len(pending) < steps; i-- {
+		migration := migrations[i]
+		if !applied[migration.Version] {
+			continue
+		}
+		if migration.Down == nil {
+			return nil, fmt.Errorf("migration %d cannot be reverted", migration.Version)
+		}
+		pending = append(pending, migration)
+	}
+	return pending, nil
+}
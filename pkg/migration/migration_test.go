@@ -0,0 +1,84 @@
+package migration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MigrationSuite struct {
+	suite.Suite
+	migrations []Migration
+}
+
+func TestMigrationSuite(t *testing.T) {
+	suite.Run(t, new(MigrationSuite))
+}
+
+func noop(ctx context.Context, mongoDBAdapter adapter.IMongoDBAdapter, database *mongo.Database) error {
+	return nil
+}
+
+func (s *MigrationSuite) SetupTest() {
+	s.migrations = []Migration{
+		{Version: 1, Up: noop, Down: noop},
+		{Version: 2, Up: noop},
+		{Version: 3, Up: noop, Down: noop},
+		{Version: 4, Up: noop, Down: noop},
+	}
+}
+
+func versions(migrations []Migration) []int64 {
+	result := []int64{}
+	for _, migration := range migrations {
+		result = append(result, migration.Version)
+	}
+	return result
+}
+
+func (s *MigrationSuite) TestPendingUp() {
+
+	s.Run("should list unapplied migrations in version order", func() {
+		pending := pendingUp(s.migrations, map[int64]bool{1: true, 3: true}, 0)
+
+		s.Equal([]int64{2, 4}, versions(pending))
+	})
+
+	s.Run("should stop at the target version", func() {
+		pending := pendingUp(s.migrations, map[int64]bool{}, 3)
+
+		s.Equal([]int64{1, 2, 3}, versions(pending))
+	})
+}
+
+func (s *MigrationSuite) TestPendingDown() {
+
+	s.Run("should list the latest applied migrations, newest first", func() {
+		pending, err := pendingDown(s.migrations, map[int64]bool{1: true, 2: true, 3: true, 4: true}, 2)
+
+		s.NoError(err)
+		s.Equal([]int64{4, 3}, versions(pending))
+	})
+
+	s.Run("should skip migrations that are not applied", func() {
+		pending, err := pendingDown(s.migrations, map[int64]bool{1: true, 3: true}, 2)
+
+		s.NoError(err)
+		s.Equal([]int64{3, 1}, versions(pending))
+	})
+
+	s.Run("should refuse to revert a migration without Down", func() {
+		_, err := pendingDown(s.migrations, map[int64]bool{1: true, 2: true, 3: true}, 2)
+
+		s.EqualError(err, "migration 2 cannot be reverted")
+	})
+
+	s.Run("should refuse when an applied migration is not registered", func() {
+		_, err := pendingDown(s.migrations, map[int64]bool{1: true, 5: true}, 1)
+
+		s.EqualError(err, "migration 5 is applied but not registered in this build")
+	})
+}
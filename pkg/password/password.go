@@ -0,0 +1,71 @@
+package password
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// IHasher hashes passwords for storage and checks them against stored hashes.
+type IHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches hash. A mismatch is not an error.
+	Verify(hash string, password string) (bool, error)
+	// NeedsRehash reports whether hash was made with other parameters than the hasher's, so the password should be
+	// hashed again the next time it is known.
+	NeedsRehash(hash string) bool
+}
+
+type HasherConfig struct {
+	// Cost is the bcrypt cost, from bcrypt.MinCost to bcrypt.MaxCost. Defaults to bcrypt.DefaultCost.
+	Cost int
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher fails when the cost is out of range, as bcrypt would otherwise hash with another cost and every
+// hash would need a rehash.
+func NewBcryptHasher(hasherConfig HasherConfig) (IHasher, error) {
+	if hasherConfig.Cost == 0 {
+		hasherConfig.Cost = bcrypt.DefaultCost
+	}
+	if hasherConfig.Cost < bcrypt.MinCost || hasherConfig.Cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost %d is outside %d to %d", hasherConfig.Cost, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &bcryptHasher{
+		cost: hasherConfig.Cost,
+	}, nil
+}
+
+func (h bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h bcryptHasher) Verify(hash string, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h bcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost
+}
+
+// IsHashed reports whether value looks like a bcrypt hash rather than a plaintext password.
+func IsHashed(value string) bool {
+	_, err := bcrypt.Cost([]byte(value))
+	return err == nil
+}
@@ -0,0 +1,92 @@
+package password_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/password"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PasswordSuite struct {
+	suite.Suite
+	hasher password.IHasher
+}
+
+func (s *PasswordSuite) SetupSuite() {
+	var err error
+	s.hasher, err = password.NewBcryptHasher(password.HasherConfig{Cost: bcrypt.MinCost})
+	s.Require().NoError(err)
+}
+
+func TestPasswordSuite(t *testing.T) {
+	suite.Run(t, new(PasswordSuite))
+}
+
+func (s *PasswordSuite) TestNewBcryptHasher() {
+	for _, cost := range []int{bcrypt.MinCost - 1, bcrypt.MaxCost + 1} {
+		_, err := password.NewBcryptHasher(password.HasherConfig{Cost: cost})
+
+		s.Error(err, cost)
+	}
+
+	s.Run("should default to bcrypt.DefaultCost", func() {
+		hasher, err := password.NewBcryptHasher(password.HasherConfig{})
+		s.Require().NoError(err)
+
+		hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+		s.False(hasher.NeedsRehash(string(hash)))
+	})
+}
+
+func (s *PasswordSuite) TestVerify() {
+	hash, err := s.hasher.Hash("A1b2C3d$")
+	s.NoError(err)
+
+	s.Run("should not store the password", func() {
+		s.NotContains(hash, "A1b2C3d$")
+		s.True(password.IsHashed(hash))
+	})
+
+	s.Run("should accept the password", func() {
+		ok, err := s.hasher.Verify(hash, "A1b2C3d$")
+
+		s.NoError(err)
+		s.True(ok)
+	})
+
+	s.Run("should reject another password without error", func() {
+		ok, err := s.hasher.Verify(hash, "wrong")
+
+		s.NoError(err)
+		s.False(ok)
+	})
+
+	s.Run("should return error when hash is malformed", func() {
+		_, err := s.hasher.Verify("plaintext", "plaintext")
+
+		s.Error(err)
+	})
+}
+
+func (s *PasswordSuite) TestNeedsRehash() {
+
+	s.Run("should not rehash hashes made with the same cost", func() {
+		hash, _ := s.hasher.Hash("password")
+
+		s.False(s.hasher.NeedsRehash(hash))
+	})
+
+	s.Run("should rehash hashes made with another cost", func() {
+		hasher, err := password.NewBcryptHasher(password.HasherConfig{Cost: bcrypt.MinCost + 1})
+		s.Require().NoError(err)
+		hash, _ := hasher.Hash("password")
+
+		s.True(s.hasher.NeedsRehash(hash))
+	})
+
+	s.Run("should rehash plaintext passwords", func() {
+		s.True(s.hasher.NeedsRehash("password"))
+		s.False(password.IsHashed("password"))
+	})
+}
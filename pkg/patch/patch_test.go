@@ -0,0 +1,110 @@
+package patch_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/patch"
+)
+
+type PatchSuite struct {
+	suite.Suite
+}
+
+func TestPatchSuite(t *testing.T) {
+	suite.Run(t, new(PatchSuite))
+}
+
+func (s *PatchSuite) TestMergePatch() {
+	doc := []byte(`{"name":"user1","username":"user1","profile":{"city":"Bangkok","zip":"10110"}}`)
+
+	s.Run("should replace, remove and merge members", func() {
+		res, err := patch.MergePatch(doc, []byte(`{"name":"user2","username":null,"profile":{"zip":null,"country":"TH"}}`))
+
+		s.NoError(err)
+		s.JSONEq(`{"name":"user2","profile":{"city":"Bangkok","country":"TH"}}`, string(res))
+	})
+
+	s.Run("should replace the document with a patch that is not an object", func() {
+		res, err := patch.MergePatch(doc, []byte(`["a"]`))
+
+		s.NoError(err)
+		s.JSONEq(`["a"]`, string(res))
+	})
+
+	s.Run("should return ErrInvalidPatch when the patch is not JSON", func() {
+		_, err := patch.MergePatch(doc, []byte(`{`))
+
+		s.ErrorIs(err, patch.ErrInvalidPatch)
+	})
+}
+
+func (s *PatchSuite) TestJSONPatch() {
+	doc := []byte(`{"name":"user1","tags":["a","b"],"profile":{"city":"Bangkok"}}`)
+
+	s.Run("should apply every operation in order", func() {
+		res, err := patch.JSONPatch(doc, []byte(`[
+			{"op":"test","path":"/name","value":"user1"},
+			{"op":"replace","path":"/name","value":"user2"},
+			{"op":"add","path":"/tags/1","value":"c"},
+			{"op":"add","path":"/tags/-","value":"d"},
+			{"op":"remove","path":"/tags/0"},
+			{"op":"copy","from":"/profile","path":"/home"},
+			{"op":"move","from":"/profile/city","path":"/city"}
+		]`))
+
+		s.NoError(err)
+		s.JSONEq(`{"name":"user2","tags":["c","b","d"],"profile":{},"home":{"city":"Bangkok"},"city":"Bangkok"}`, string(res))
+	})
+
+	s.Run("should unescape ~0 and ~1 in paths", func() {
+		res, err := patch.JSONPatch([]byte(`{"a/b":1,"c~d":2}`), []byte(`[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/c~0d"}]`))
+
+		s.NoError(err)
+		s.JSONEq(`{}`, string(res))
+	})
+
+	s.Run("should return ErrUnprocessable when a test fails", func() {
+		_, err := patch.JSONPatch(doc, []byte(`[{"op":"test","path":"/name","value":"user2"}]`))
+
+		s.ErrorIs(err, patch.ErrUnprocessable)
+	})
+
+	s.Run("should return ErrUnprocessable when the path does not exist", func() {
+		_, err := patch.JSONPatch(doc, []byte(`[{"op":"replace","path":"/missing","value":1}]`))
+
+		s.ErrorIs(err, patch.ErrUnprocessable)
+	})
+
+	s.Run("should return ErrInvalidPatch for an unknown op", func() {
+		_, err := patch.JSONPatch(doc, []byte(`[{"op":"merge","path":"/name","value":1}]`))
+
+		s.ErrorIs(err, patch.ErrInvalidPatch)
+	})
+
+	s.Run("should return ErrInvalidPatch for an add without a value", func() {
+		_, err := patch.JSONPatch(doc, []byte(`[{"op":"add","path":"/name"}]`))
+
+		s.ErrorIs(err, patch.ErrInvalidPatch)
+	})
+}
+
+func (s *PatchSuite) TestFields() {
+
+	s.Run("should list the changed and removed members", func() {
+		set, unset, err := patch.Fields(
+			[]byte(`{"name":"user1","username":"user1","city":"Bangkok"}`),
+			[]byte(`{"name":"user2","username":"user1","country":"TH"}`),
+		)
+
+		s.NoError(err)
+		s.Equal([]string{"country", "name"}, set)
+		s.Equal([]string{"city"}, unset)
+	})
+
+	s.Run("should return ErrUnprocessable when the document is no longer an object", func() {
+		_, _, err := patch.Fields([]byte(`{"name":"user1"}`), []byte(`["user1"]`))
+
+		s.ErrorIs(err, patch.ErrUnprocessable)
+	})
+}
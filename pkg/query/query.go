@@ -0,0 +1,211 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+)
+
+// Operator compares a field with a value.
+type Operator string
+
+const (
+	Equal          Operator = "eq"
+	NotEqual       Operator = "ne"
+	Prefix         Operator = "prefix"
+	GreaterThan    Operator = "gt"
+	GreaterOrEqual Operator = "gte"
+	LessThan       Operator = "lt"
+	LessOrEqual    Operator = "lte"
+	// In matches any of a comma-separated list of values.
+	In Operator = "in"
+)
+
+// FieldType says how the values of a field are parsed.
+type FieldType int
+
+const (
+	String FieldType = iota
+	// Time values are RFC 3339 timestamps or dates such as 2006-01-02.
+	Time
+)
+
+// Field is a field clients may filter or sort by.
+type Field struct {
+	// Path is the stored field, which may differ from the name clients use.
+	Path      string
+	Type      FieldType
+	Operators []Operator
+	Sortable  bool
+}
+
+// Schema is the allowlist of what clients may query. Anything else is rejected.
+type Schema struct {
+	Fields map[string]Field
+	// TextFields are the paths matched by the free text parameter.
+	TextFields []string
+	// TextParam names the free text parameter. Defaults to "q".
+	TextParam string
+	// MaxValueLength bounds every value. Defaults to 256.
+	MaxValueLength int
+}
+
+// Condition is a parsed field[op]=value parameter.
+type Condition struct {
+	Path     string
+	Operator Operator
+	// Value is a string or time.Time, or a slice of them for In.
+	Value interface{}
+}
+
+// Query is a parsed filter. Every condition must match, and the free text must appear in one of the text fields.
+type Query struct {
+	Conditions []Condition
+	Text       string
+	TextFields []string
+}
+
+// Parse parses query parameters of the form field[op]=value, or field=value for eq, and the free text parameter.
+// Parameters listed in ignore, such as the paging ones, are skipped. Fields and operators outside schema are
+// rejected with apperror.InvalidArgument, so every value ends up compared as a literal.
+func Parse(schema Schema, values url.Values, ignore ...string) (Query, error) {
+	if schema.TextParam == "" {
+		schema.TextParam = "q"
+	}
+	if schema.MaxValueLength == 0 {
+		schema.MaxValueLength = 256
+	}
+
+	// Sorting the parameters makes the conditions, and so the filter, the same for the same query.
+	params := make([]string, 0, len(values))
+	for param := range values {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	query := Query{Conditions: []Condition{}}
+	for _, param := range params {
+		if contains(ignore, param) {
+			continue
+		}
+
+		for _, raw := range values[param] {
+			if len(raw) > schema.MaxValueLength {
+				return Query{}, invalid(fmt.Sprintf("%s is longer than %d characters", param, schema.MaxValueLength))
+			}
+
+			if param == schema.TextParam {
+				if len(schema.TextFields) == 0 {
+					return Query{}, invalid("free text search is not supported")
+				}
+				query.Text = strings.TrimSpace(raw)
+				query.TextFields = schema.TextFields
+				continue
+			}
+
+			condition, err := parseCondition(schema, param, raw)
+			if err != nil {
+				return Query{}, err
+			}
+			query.Conditions = append(query.Conditions, condition)
+		}
+	}
+
+	return query, nil
+}
+
+func parseCondition(schema Schema, param string, raw string) (Condition, error) {
+	name, operator := param, Equal
+	if i := strings.IndexByte(param, '['); i >= 0 {
+		if !strings.HasSuffix(param, "]") {
+			return Condition{}, invalid(fmt.Sprintf("%s is not a valid filter", param))
+		}
+		name, operator = param[:i], Operator(param[i+1:len(param)-1])
+	}
+
+	field, ok := schema.Fields[name]
+	if !ok {
+		return Condition{}, invalid(fmt.Sprintf("filtering by %s is not supported", name))
+	}
+	if !containsOperator(field.Operators, operator) {
+		return Condition{}, invalid(fmt.Sprintf("%s does not support %s", name, operator))
+	}
+
+	if operator == In {
+		items := strings.Split(raw, ",")
+		parsed := make([]interface{}, len(items))
+		for i, item := range items {
+			value, err := parseValue(field.Type, name, item)
+			if err != nil {
+				return Condition{}, err
+			}
+			parsed[i] = value
+		}
+		return Condition{Path: field.Path, Operator: operator, Value: parsed}, nil
+	}
+
+	value, err := parseValue(field.Type, name, raw)
+	if err != nil {
+		return Condition{}, err
+	}
+	return Condition{Path: field.Path, Operator: operator, Value: value}, nil
+}
+
+func parseValue(fieldType FieldType, name string, raw string) (interface{}, error) {
+	if fieldType != Time {
+		return raw, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if value, err := time.Parse(layout, raw); err == nil {
+			return value, nil
+		}
+	}
+	return nil, invalid(fmt.Sprintf("%s must be an RFC 3339 time or a date such as 2006-01-02", name))
+}
+
+// ParseSort parses a comma-separated list of sortable fields, each descending when prefixed with -.
+func ParseSort(schema Schema, value string) ([]adapter.SortField, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	fields := []adapter.SortField{}
+	for _, key := range strings.Split(value, ",") {
+		descending := strings.HasPrefix(key, "-")
+		field, ok := schema.Fields[strings.TrimPrefix(key, "-")]
+		if !ok || !field.Sortable {
+			return nil, invalid(fmt.Sprintf("sort by %q is not supported", key))
+		}
+		fields = append(fields, adapter.SortField{Field: field.Path, Descending: descending})
+	}
+
+	return fields, nil
+}
+
+func invalid(message string) error {
+	return apperror.NewError(message, message, apperror.InvalidArgument)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsOperator(operators []Operator, operator Operator) bool {
+	for _, o := range operators {
+		if o == operator {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package query_test
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/query"
+)
+
+type QuerySuite struct {
+	suite.Suite
+	schema query.Schema
+}
+
+func (s *QuerySuite) SetupSuite() {
+	s.schema = query.Schema{
+		Fields: map[string]query.Field{
+			"name":       {Path: "name", Operators: []query.Operator{query.Equal, query.Prefix}, Sortable: true},
+			"username":   {Path: "username", Operators: []query.Operator{query.Equal, query.In}},
+			"birth_date": {Path: "birth_date", Type: query.Time, Operators: []query.Operator{query.GreaterOrEqual, query.LessThan}, Sortable: true},
+		},
+		TextFields: []string{"name", "username"},
+	}
+}
+
+func TestQuerySuite(t *testing.T) {
+	suite.Run(t, new(QuerySuite))
+}
+
+func (s *QuerySuite) TestParse() {
+
+	s.Run("should parse conditions and free text", func() {
+		values, _ := url.ParseQuery("name[prefix]=jo&birth_date[gte]=1990-01-01&birth_date[lt]=2000-01-01T00:00:00Z&username[in]=a,b&q=john&limit=10")
+
+		res, err := query.Parse(s.schema, values, "limit")
+
+		s.Nil(err)
+		s.Equal(query.Query{
+			Conditions: []query.Condition{
+				{Path: "birth_date", Operator: query.GreaterOrEqual, Value: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{Path: "birth_date", Operator: query.LessThan, Value: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)},
+				{Path: "name", Operator: query.Prefix, Value: "jo"},
+				{Path: "username", Operator: query.In, Value: []interface{}{"a", "b"}},
+			},
+			Text:       "john",
+			TextFields: []string{"name", "username"},
+		}, res)
+	})
+
+	s.Run("should treat a bare field as eq", func() {
+		values, _ := url.ParseQuery("username=john")
+
+		res, err := query.Parse(s.schema, values)
+
+		s.Nil(err)
+		s.Equal([]query.Condition{{Path: "username", Operator: query.Equal, Value: "john"}}, res.Conditions)
+	})
+
+	s.Run("should keep operators in values as literals", func() {
+		values, _ := url.ParseQuery(`username={"$where":"sleep(1000)"}`)
+
+		res, err := query.Parse(s.schema, values)
+
+		s.Nil(err)
+		s.Equal(`{"$where":"sleep(1000)"}`, res.Conditions[0].Value)
+	})
+
+	for name, raw := range map[string]string{
+		"a field outside the schema":         "password=secret",
+		"an operator as a field":             "$where=1",
+		"an operator outside the schema":     "name[$where]=1",
+		"an operator the field doesn't have": "name[gt]=a",
+		"a malformed filter":                 "name[prefix=a",
+		"a malformed time":                   "birth_date[gte]=yesterday",
+		"a value that is too long":           "name=" + strings.Repeat("a", 257),
+	} {
+		raw := raw
+		s.Run("should reject "+name, func() {
+			values, _ := url.ParseQuery(raw)
+
+			_, err := query.Parse(s.schema, values)
+
+			var appErr apperror.AppError
+			s.True(errors.As(err, &appErr))
+			s.Equal(apperror.InvalidArgument, appErr.Code)
+		})
+	}
+}
+
+func (s *QuerySuite) TestParseSort() {
+
+	s.Run("should parse sortable fields in order", func() {
+		res, err := query.ParseSort(s.schema, "name,-birth_date")
+
+		s.Nil(err)
+		s.Equal([]adapter.SortField{{Field: "name"}, {Field: "birth_date", Descending: true}}, res)
+	})
+
+	s.Run("should reject fields that are not sortable", func() {
+		_, err := query.ParseSort(s.schema, "username")
+
+		s.Error(err)
+	})
+}
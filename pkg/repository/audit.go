@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type IAudit interface {
+	Add(ctx context.Context, record *entity.AuditRecord) error
+	// List pages through the audit records of an entity, newest first.
+	List(ctx context.Context, entityType string, entityID string, pagination adapter.Pagination) ([]entity.AuditRecord, adapter.PageInfo, error)
+}
+
+// AuditIndexes serve the history of an entity, newest first.
+var AuditIndexes = []adapter.Index{
+	{
+		Name: "entity_timestamp",
+		Keys: []adapter.IndexKey{
+			{Field: "entity_type"},
+			{Field: "entity_id"},
+			{Field: "timestamp", Descending: true},
+		},
+	},
+}
+
+type AuditConfig struct {
+	Timeout time.Duration
+}
+
+type audit struct {
+	base IRepository[entity.AuditRecord]
+}
+
+func NewAudit(auditConfig AuditConfig, mongoDBAdapter adapter.IMongoDBAdapter, auditCollection adapter.IMongoCollection) IAudit {
+	return &audit{
+		base: NewRepository[entity.AuditRecord](RepositoryConfig{
+			Timeout:    auditConfig.Timeout,
+			EntityName: "Audit record",
+		}, mongoDBAdapter, auditCollection),
+	}
+}
+
+func (r audit) Add(ctx context.Context, record *entity.AuditRecord) error {
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+
+	id, err := r.base.Create(ctx, record)
+
+	if err != nil {
+		return err
+	}
+
+	record.ID = id
+
+	return nil
+}
+
+func (r audit) List(ctx context.Context, entityType string, entityID string, pagination adapter.Pagination) ([]entity.AuditRecord, adapter.PageInfo, error) {
+	pagination.Sort = []adapter.SortField{{Field: "timestamp", Descending: true}}
+
+	return r.base.Find(ctx, bson.D{
+		{Key: "entity_type", Value: entityType},
+		{Key: "entity_id", Value: entityID},
+	}, pagination)
+}
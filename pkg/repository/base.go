@@ -0,0 +1,549 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IRepository is the data access every entity stored by ObjectID needs. Methods take the caller's ctx so they
+// can run inside a transaction, and bound it by the configured timeout. Driver errors come back as apperror codes.
+type IRepository[T any] interface {
+	GetByID(ctx context.Context, id string) (T, error)
+	// FindOne returns the first document matching filter.
+	FindOne(ctx context.Context, filter interface{}) (T, error)
+	Find(ctx context.Context, filter interface{}, pagination adapter.Pagination) ([]T, adapter.PageInfo, error)
+	Count(ctx context.Context, filter interface{}) (int64, error)
+	// Each calls fn with every document matching filter in _id order, holding only the current batch in memory. It
+	// is bounded by ctx alone, not the configured timeout, so it can stream large collections.
+	Each(ctx context.Context, filter interface{}, fn func(document T) error) error
+	// EachWithDeleted is Each including soft-deleted documents.
+	EachWithDeleted(ctx context.Context, filter interface{}, fn func(document T) error) error
+	// Create inserts document and returns its new ID. Any ID set on document is ignored.
+	Create(ctx context.Context, document *T) (string, error)
+	// CreateMany inserts documents and returns their new IDs. The documents that cannot be inserted fail it with
+	// WriteErrors, while the others are still inserted unless ctx is in a transaction.
+	CreateMany(ctx context.Context, documents []T) ([]string, error)
+	// Update replaces every field of the document with the given ID, except _id and the create-only fields, with
+	// those of document.
+	Update(ctx context.Context, id string, document *T) error
+	// UpdateVersion is Update for documents with a version field. It only updates the document while its version
+	// is still version, failing with apperror.VersionConflict otherwise, and increments the version.
+	UpdateVersion(ctx context.Context, id string, version int64, document *T) error
+	// PatchVersion is UpdateVersion for only some fields: it sets the fields in set and removes the ones in unset.
+	// _id, the create-only fields and the version cannot be patched.
+	PatchVersion(ctx context.Context, id string, version int64, set bson.D, unset []string) error
+	// SetFields sets only the given fields, including create-only ones, of the document with the given ID.
+	SetFields(ctx context.Context, id string, fields bson.D) error
+	Delete(ctx context.Context, id string) error
+	// GetDeletedByID returns a soft-deleted document, failing with apperror.NotFound unless it is deleted.
+	GetDeletedByID(ctx context.Context, id string) (T, error)
+	// Restore undoes a soft delete.
+	Restore(ctx context.Context, id string) error
+	// Purge removes documents that were soft deleted before the cutoff for good.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type RepositoryConfig struct {
+	Timeout time.Duration
+	// EntityName names the entity in errors, as in "User not found".
+	EntityName string
+	// CreateOnlyFields are set by Create and never changed by Update.
+	CreateOnlyFields []string
+	// SoftDelete makes Delete set deleted_at instead of removing the document. Every other method except
+	// GetDeletedByID, Restore and Purge then treats the document as missing.
+	SoftDelete bool
+}
+
+const (
+	// versionField holds the version of documents updated with UpdateVersion.
+	versionField = "version"
+	// deletedAtField holds when a document was soft deleted.
+	deletedAtField = "deleted_at"
+)
+
+type mongoRepository[T any] struct {
+	mongoDBAdapter adapter.IMongoDBAdapter
+	collection     adapter.IMongoCollection
+	timeout        time.Duration
+	entityName     string
+	createOnly     []string
+	softDelete     bool
+}
+
+func NewRepository[T any](repositoryConfig RepositoryConfig, mongoDBAdapter adapter.IMongoDBAdapter, collection adapter.IMongoCollection) IRepository[T] {
+	return &mongoRepository[T]{
+		mongoDBAdapter: mongoDBAdapter,
+		collection:     collection,
+		timeout:        repositoryConfig.Timeout,
+		entityName:     repositoryConfig.EntityName,
+		createOnly:     repositoryConfig.CreateOnlyFields,
+		softDelete:     repositoryConfig.SoftDelete,
+	}
+}
+
+func (r mongoRepository[T]) GetByID(ctx context.Context, id string) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var document T
+
+	filter, err := r.idFilter(id)
+
+	if err != nil {
+		return document, err
+	}
+
+	err = r.mongoDBAdapter.FindOne(ctx, r.collection, &document, r.scope(filter))
+
+	if err != nil {
+		return document, mongoError(err, r.entityName)
+	}
+
+	return document, nil
+}
+
+func (r mongoRepository[T]) FindOne(ctx context.Context, filter interface{}) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var document T
+
+	err := r.mongoDBAdapter.FindOne(ctx, r.collection, &document, r.scope(filter))
+
+	if err != nil {
+		return document, mongoError(err, r.entityName)
+	}
+
+	return document, nil
+}
+
+func (r mongoRepository[T]) Find(ctx context.Context, filter interface{}, pagination adapter.Pagination) ([]T, adapter.PageInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	documents := []T{}
+
+	pageInfo, err := r.mongoDBAdapter.FindPage(ctx, r.collection, &documents, r.scope(filter), pagination)
+
+	if err != nil {
+		if errors.Is(err, adapter.ErrInvalidCursor) {
+			return nil, adapter.PageInfo{}, apperror.NewError(
+				"Invalid cursor",
+				"The cursor is malformed or was issued for a different sort",
+				apperror.InvalidArgument,
+			)
+		}
+		return nil, adapter.PageInfo{}, mongoError(err, r.entityName)
+	}
+
+	return documents, pageInfo, nil
+}
+
+func (r mongoRepository[T]) Count(ctx context.Context, filter interface{}) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	count, err := r.mongoDBAdapter.CountDocuments(ctx, r.collection, r.scope(filter))
+
+	if err != nil {
+		return 0, mongoError(err, r.entityName)
+	}
+
+	return count, nil
+}
+
+func (r mongoRepository[T]) Each(ctx context.Context, filter interface{}, fn func(document T) error) error {
+	return r.each(ctx, r.scope(filter), fn)
+}
+
+func (r mongoRepository[T]) EachWithDeleted(ctx context.Context, filter interface{}, fn func(document T) error) error {
+	return r.each(ctx, filter, fn)
+}
+
+func (r mongoRepository[T]) each(ctx context.Context, filter interface{}, fn func(document T) error) error {
+	err := r.mongoDBAdapter.FindEach(ctx, r.collection, filter, func(decode adapter.DecodeFunc) error {
+		var document T
+		if err := decode(&document); err != nil {
+			return err
+		}
+		return fn(document)
+	}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+
+	if err != nil {
+		return mongoError(err, r.entityName)
+	}
+
+	return nil
+}
+
+func (r mongoRepository[T]) Create(ctx context.Context, document *T) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	fields, err := documentFields(document, "_id")
+
+	if err != nil {
+		return "", err
+	}
+
+	primitiveObjectID, err := r.mongoDBAdapter.InsertOne(ctx, r.collection, fields)
+
+	if err != nil {
+		return "", mongoError(err, r.entityName)
+	}
+
+	return primitiveObjectID.Hex(), nil
+}
+
+func (r mongoRepository[T]) CreateMany(ctx context.Context, documents []T) ([]string, error) {
+	if len(documents) == 0 {
+		return []string{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	fields := make([]interface{}, len(documents))
+	for i := range documents {
+		document, err := documentFields(&documents[i], "_id")
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields[i] = document
+	}
+
+	primitiveObjectIDs, err := r.mongoDBAdapter.InsertMany(ctx, r.collection, fields, options.InsertMany().SetOrdered(false))
+
+	if err != nil {
+		return nil, writeErrors(err, r.entityName)
+	}
+
+	ids := make([]string, len(primitiveObjectIDs))
+	for i, primitiveObjectID := range primitiveObjectIDs {
+		ids[i] = primitiveObjectID.Hex()
+	}
+
+	return ids, nil
+}
+
+func (r mongoRepository[T]) Update(ctx context.Context, id string, document *T) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter, err := r.idFilter(id)
+
+	if err != nil {
+		return err
+	}
+
+	fields, err := documentFields(document, r.readOnly()...)
+
+	if err != nil {
+		return err
+	}
+
+	isSuccess, err := r.mongoDBAdapter.UpdateOne(ctx, r.collection, r.scope(filter), bson.D{{Key: "$set", Value: fields}})
+
+	if err != nil {
+		return mongoError(err, r.entityName)
+	}
+
+	if !isSuccess {
+		return r.notFound()
+	}
+
+	return nil
+}
+
+func (r mongoRepository[T]) UpdateVersion(ctx context.Context, id string, version int64, document *T) error {
+	fields, err := documentFields(document, append(r.readOnly(), versionField)...)
+
+	if err != nil {
+		return err
+	}
+
+	return r.updateVersion(ctx, id, version, bson.D{{Key: "$set", Value: fields}})
+}
+
+func (r mongoRepository[T]) PatchVersion(ctx context.Context, id string, version int64, set bson.D, unset []string) error {
+	readOnly := append(r.readOnly(), versionField)
+
+	update := bson.D{}
+	if len(set) > 0 {
+		for _, field := range set {
+			if contains(readOnly, field.Key) {
+				return r.readOnlyField(field.Key)
+			}
+		}
+		update = append(update, bson.E{Key: "$set", Value: set})
+	}
+	if len(unset) > 0 {
+		fields := bson.D{}
+		for _, field := range unset {
+			if contains(readOnly, field) {
+				return r.readOnlyField(field)
+			}
+			fields = append(fields, bson.E{Key: field, Value: ""})
+		}
+		update = append(update, bson.E{Key: "$unset", Value: fields})
+	}
+
+	return r.updateVersion(ctx, id, version, update)
+}
+
+// updateVersion applies update and increments the version of the document with the given ID while it is at version.
+func (r mongoRepository[T]) updateVersion(ctx context.Context, id string, version int64, update bson.D) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter, err := r.idFilter(id)
+
+	if err != nil {
+		return err
+	}
+
+	isSuccess, err := r.mongoDBAdapter.UpdateOne(ctx, r.collection, r.scope(append(filter, versionFilter(version))),
+		append(update, bson.E{Key: "$inc", Value: bson.D{{Key: versionField, Value: 1}}}),
+	)
+
+	if err != nil {
+		return mongoError(err, r.entityName)
+	}
+
+	if isSuccess {
+		return nil
+	}
+
+	count, err := r.mongoDBAdapter.CountDocuments(ctx, r.collection, r.scope(filter))
+
+	if err != nil {
+		return mongoError(err, r.entityName)
+	}
+
+	if count == 0 {
+		return r.notFound()
+	}
+
+	return apperror.NewError(
+		fmt.Sprintf("%s was modified", r.entityName),
+		fmt.Sprintf("%s is no longer at version %d", r.entityName, version),
+		apperror.VersionConflict,
+	)
+}
+
+func (r mongoRepository[T]) SetFields(ctx context.Context, id string, fields bson.D) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter, err := r.idFilter(id)
+
+	if err != nil {
+		return err
+	}
+
+	isSuccess, err := r.mongoDBAdapter.UpdateOne(ctx, r.collection, r.scope(filter), bson.D{{Key: "$set", Value: fields}})
+
+	if err != nil {
+		return mongoError(err, r.entityName)
+	}
+
+	if !isSuccess {
+		return r.notFound()
+	}
+
+	return nil
+}
+
+func (r mongoRepository[T]) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter, err := r.idFilter(id)
+
+	if err != nil {
+		return err
+	}
+
+	var isSuccess bool
+	if r.softDelete {
+		isSuccess, err = r.mongoDBAdapter.UpdateOne(ctx, r.collection, r.scope(filter), bson.D{
+			{Key: "$set", Value: bson.D{{Key: deletedAtField, Value: time.Now()}}},
+		})
+	} else {
+		isSuccess, err = r.mongoDBAdapter.DeleteOne(ctx, r.collection, filter)
+	}
+
+	if err != nil {
+		return mongoError(err, r.entityName)
+	}
+
+	if !isSuccess {
+		return r.notFound()
+	}
+
+	return nil
+}
+
+func (r mongoRepository[T]) GetDeletedByID(ctx context.Context, id string) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var document T
+
+	filter, err := r.idFilter(id)
+
+	if err != nil {
+		return document, err
+	}
+
+	err = r.mongoDBAdapter.FindOne(ctx, r.collection, &document, append(filter, deletedFilter()))
+
+	if errors.Is(err, adapter.ErrNoDocuments) {
+		return document, r.deletedNotFound()
+	}
+
+	if err != nil {
+		return document, mongoError(err, r.entityName)
+	}
+
+	return document, nil
+}
+
+func (r mongoRepository[T]) Restore(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filter, err := r.idFilter(id)
+
+	if err != nil {
+		return err
+	}
+
+	isSuccess, err := r.mongoDBAdapter.UpdateOne(ctx, r.collection,
+		append(filter, deletedFilter()),
+		bson.D{{Key: "$unset", Value: bson.D{{Key: deletedAtField, Value: ""}}}},
+	)
+
+	if err != nil {
+		return mongoError(err, r.entityName)
+	}
+
+	if !isSuccess {
+		return r.deletedNotFound()
+	}
+
+	return nil
+}
+
+func (r mongoRepository[T]) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleted, err := r.mongoDBAdapter.DeleteMany(ctx, r.collection, bson.D{
+		{Key: deletedAtField, Value: bson.D{{Key: "$lt", Value: before}}},
+	})
+
+	if err != nil {
+		return 0, mongoError(err, r.entityName)
+	}
+
+	return deleted, nil
+}
+
+func (r mongoRepository[T]) idFilter(id string) (bson.D, error) {
+	primitiveObjectID, err := objectID(id, r.entityName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.D{{Key: "_id", Value: primitiveObjectID}}, nil
+}
+
+// scope hides soft-deleted documents from filter.
+func (r mongoRepository[T]) scope(filter interface{}) interface{} {
+	if !r.softDelete {
+		return filter
+	}
+	return bson.D{{Key: "$and", Value: bson.A{filter, bson.D{{Key: deletedAtField, Value: nil}}}}}
+}
+
+// readOnly lists the fields Update never sets.
+func (r mongoRepository[T]) readOnly() []string {
+	fields := append([]string{"_id"}, r.createOnly...)
+	if r.softDelete {
+		fields = append(fields, deletedAtField)
+	}
+	return fields
+}
+
+func (r mongoRepository[T]) readOnlyField(field string) error {
+	return apperror.NewError(
+		fmt.Sprintf("%s cannot be changed", field),
+		fmt.Sprintf("%s.%s cannot be changed by an update", r.entityName, field),
+		apperror.InvalidArgument,
+	)
+}
+
+func (r mongoRepository[T]) notFound() error {
+	return mongoError(adapter.ErrNoDocuments, r.entityName)
+}
+
+func (r mongoRepository[T]) deletedNotFound() error {
+	message := fmt.Sprintf("Deleted %s not found", strings.ToLower(r.entityName))
+	return apperror.NewError(message, message, apperror.NotFound)
+}
+
+// deletedFilter matches soft-deleted documents.
+func deletedFilter() bson.E {
+	return bson.E{Key: deletedAtField, Value: bson.D{{Key: "$ne", Value: nil}}}
+}
+
+// versionFilter matches documents at version. Documents written before they were versioned have no version field
+// and count as version 0.
+func versionFilter(version int64) bson.E {
+	if version == 0 {
+		return bson.E{Key: versionField, Value: bson.D{{Key: "$in", Value: bson.A{0, nil}}}}
+	}
+	return bson.E{Key: versionField, Value: version}
+}
+
+// documentFields encodes document as a bson document without the omitted fields.
+func documentFields(document interface{}, omit ...string) (bson.D, error) {
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields bson.D
+	if err := bson.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	kept := fields[:0]
+	for _, field := range fields {
+		if !contains(omit, field.Key) {
+			kept = append(kept, field)
+		}
+	}
+	return kept, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
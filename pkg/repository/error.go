@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// mongoError translates a driver error into an apperror.AppError. AppErrors and errors without a translation are
+// returned as they are.
+func mongoError(err error, entityName string) error {
+	var appErr apperror.AppError
+	if err == nil || errors.As(err, &appErr) {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, adapter.ErrNoDocuments):
+		message := fmt.Sprintf("%s not found", entityName)
+		return apperror.NewError(message, message, apperror.NotFound)
+	case mongo.IsDuplicateKeyError(err):
+		return apperror.NewError(
+			fmt.Sprintf("%s already exists", entityName),
+			err.Error(),
+			apperror.DuplicateKey,
+		)
+	case mongo.IsTimeout(err):
+		return apperror.NewError(
+			"Database timeout",
+			err.Error(),
+			apperror.Timeout,
+		)
+	case errors.Is(err, primitive.ErrInvalidHex):
+		return apperror.NewError(
+			fmt.Sprintf("Invalid %s ID", entityName),
+			err.Error(),
+			apperror.InvalidID,
+		)
+	}
+
+	return err
+}
+
+// objectID parses a hex ID, failing with apperror.InvalidID.
+func objectID(id string, entityName string) (primitive.ObjectID, error) {
+	primitiveObjectID, err := primitive.ObjectIDFromHex(id)
+
+	if err != nil {
+		return primitive.NilObjectID, apperror.NewError(
+			fmt.Sprintf("Invalid %s ID", entityName),
+			fmt.Sprintf("%q is not a valid ID", id),
+			apperror.InvalidID,
+		)
+	}
+
+	return primitiveObjectID, nil
+}
+
+// WriteErrors are the errors of the documents a batch write could not write, by their index in the batch.
+type WriteErrors map[int]error
+
+func (e WriteErrors) Error() string {
+	indexes := make([]int, 0, len(e))
+	for i := range e {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	messages := make([]string, len(indexes))
+	for j, i := range indexes {
+		messages[j] = fmt.Sprintf("%d: %s", i, e[i])
+	}
+	return strings.Join(messages, "; ")
+}
+
+// writeErrors translates the write errors of a bulk write into WriteErrors. Other errors go through mongoError.
+func writeErrors(err error, entityName string) error {
+	var bulkWriteException mongo.BulkWriteException
+	if !errors.As(err, &bulkWriteException) || len(bulkWriteException.WriteErrors) == 0 {
+		return mongoError(err, entityName)
+	}
+
+	errs := WriteErrors{}
+	for _, writeErr := range bulkWriteException.WriteErrors {
+		errs[writeErr.Index] = mongoError(mongo.WriteException{WriteErrors: mongo.WriteErrors{writeErr.WriteError}}, entityName)
+	}
+	return errs
+}
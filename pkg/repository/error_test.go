@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ErrorSuite struct {
+	suite.Suite
+}
+
+func TestErrorSuite(t *testing.T) {
+	suite.Run(t, new(ErrorSuite))
+}
+
+func (s *ErrorSuite) TestMongoError() {
+	cases := []struct {
+		name string
+		err  error
+		code apperror.AppErrorCode
+	}{
+		{"no documents", adapter.ErrNoDocuments, apperror.NotFound},
+		{"wrapped no documents", fmt.Errorf("find: %w", adapter.ErrNoDocuments), apperror.NotFound},
+		{"duplicate key", mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}, apperror.DuplicateKey},
+		{"deadline exceeded", context.DeadlineExceeded, apperror.Timeout},
+	}
+
+	for _, c := range cases {
+		s.Run(fmt.Sprintf("should map %s to %s", c.name, c.code), func() {
+			var appErr apperror.AppError
+			s.True(errors.As(mongoError(c.err, "User"), &appErr))
+			s.Equal(c.code, appErr.Code)
+		})
+	}
+
+	s.Run("should keep app errors as they are", func() {
+		err := apperror.NewError("Dead job not found", "Dead job not found", apperror.NotFound)
+
+		s.Equal(err, mongoError(err, "Job"))
+	})
+
+	s.Run("should keep unknown errors as they are", func() {
+		err := errors.New("boom")
+
+		s.Equal(err, mongoError(err, "User"))
+	})
+}
+
+func (s *ErrorSuite) TestObjectID() {
+	s.Run("should return invalid id when id is not hex", func() {
+		_, err := objectID("nope", "User")
+
+		var appErr apperror.AppError
+		s.True(errors.As(err, &appErr))
+		s.Equal(apperror.InvalidID, appErr.Code)
+	})
+
+	s.Run("should parse a valid id", func() {
+		id, err := objectID("63dccac268616ec85ccfcfd2", "User")
+
+		s.NoError(err)
+		s.Equal("63dccac268616ec85ccfcfd2", id.Hex())
+	})
+}
+
+func (s *ErrorSuite) TestWriteErrors() {
+	s.Run("should map each write error to the index of its document", func() {
+		err := writeErrors(mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+			{WriteError: mongo.WriteError{Index: 1, Code: 11000}},
+			{WriteError: mongo.WriteError{Index: 3, Code: 11000}},
+		}}, "User")
+
+		var errs WriteErrors
+		s.Require().True(errors.As(err, &errs))
+		s.Len(errs, 2)
+		for _, i := range []int{1, 3} {
+			var appErr apperror.AppError
+			s.True(errors.As(errs[i], &appErr))
+			s.Equal(apperror.DuplicateKey, appErr.Code)
+		}
+	})
+
+	s.Run("should translate errors without write errors like mongoError", func() {
+		var appErr apperror.AppError
+		s.True(errors.As(writeErrors(context.DeadlineExceeded, "User"), &appErr))
+		s.Equal(apperror.Timeout, appErr.Code)
+	})
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IndexConfig struct {
+	UserCollection          string
+	JobCollection           string
+	JobDeadLetterCollection string
+	OutboxCollection        string
+	AuditCollection         string
+}
+
+// Indexes declares the indexes of every collection the application owns.
+func Indexes(indexConfig IndexConfig, database *mongo.Database) []adapter.IndexSpec {
+	return []adapter.IndexSpec{
+		{Collection: database.Collection(indexConfig.UserCollection), Indexes: UserIndexes},
+		{Collection: database.Collection(indexConfig.JobCollection), Indexes: adapter.QueueIndexes},
+		{Collection: database.Collection(indexConfig.JobDeadLetterCollection), Indexes: adapter.DeadLetterIndexes},
+		{Collection: database.Collection(indexConfig.OutboxCollection), Indexes: adapter.OutboxIndexes},
+		{Collection: database.Collection(indexConfig.AuditCollection), Indexes: AuditIndexes},
+	}
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type JobFilter struct {
+	State entity.JobState
+	Type  string
+}
+
+type IJob interface {
+	List(ctx context.Context, filter JobFilter, page int64, limit int64) ([]entity.Job, int64, error)
+	GetByID(ctx context.Context, id string) (entity.Job, error)
+	Requeue(ctx context.Context, id string) (entity.Job, error)
+	PurgeCompleted(ctx context.Context, before time.Time) (int64, error)
+}
+
+type JobConfig struct {
+	Timeout time.Duration
+}
+
+type job struct {
+	mongoDBAdapter       adapter.IMongoDBAdapter
+	jobCollection        adapter.IMongoCollection
+	deadLetterCollection adapter.IMongoCollection
+	timeout              time.Duration
+}
+
+func NewJob(jobConfig JobConfig, mongoDBAdapter adapter.IMongoDBAdapter, jobCollection adapter.IMongoCollection, deadLetterCollection adapter.IMongoCollection) IJob {
+	return &job{
+		mongoDBAdapter:       mongoDBAdapter,
+		jobCollection:        jobCollection,
+		deadLetterCollection: deadLetterCollection,
+		timeout:              jobConfig.Timeout,
+	}
+}
+
+// List pages through jobs, newest first. Dead jobs are read from the dead-letter collection.
+func (r job) List(ctx context.Context, filter JobFilter, page int64, limit int64) ([]entity.Job, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	collection := r.jobCollection
+	query := bson.D{}
+	if filter.State == entity.JobStateDead {
+		collection = r.deadLetterCollection
+	} else if filter.State != "" {
+		query = append(query, bson.E{Key: "state", Value: filter.State})
+	}
+	if filter.Type != "" {
+		query = append(query, bson.E{Key: "type", Value: filter.Type})
+	}
+
+	total, err := r.mongoDBAdapter.CountDocuments(ctx, collection, query)
+	if err != nil {
+		return nil, 0, mongoError(err, "Job")
+	}
+
+	jobs := []entity.Job{}
+	err = r.mongoDBAdapter.Find(ctx, collection, &jobs, query, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip((page-1)*limit).
+		SetLimit(limit),
+	)
+	if err != nil {
+		return nil, 0, mongoError(err, "Job")
+	}
+
+	return jobs, total, nil
+}
+
+// GetByID finds a job in the queue or, failing that, in the dead-letter collection.
+func (r job) GetByID(ctx context.Context, id string) (entity.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	primitiveObjectID, err := objectID(id, "Job")
+
+	if err != nil {
+		return entity.Job{}, err
+	}
+
+	var job entity.Job
+	filter := bson.D{{Key: "_id", Value: primitiveObjectID}}
+
+	for _, collection := range []adapter.IMongoCollection{r.jobCollection, r.deadLetterCollection} {
+		err = r.mongoDBAdapter.FindOne(ctx, collection, &job, filter)
+		if err == nil {
+			return job, nil
+		}
+		if !errors.Is(err, adapter.ErrNoDocuments) {
+			return entity.Job{}, mongoError(err, "Job")
+		}
+	}
+
+	return entity.Job{}, apperror.NewError(
+		"Job not found",
+		"Job not found",
+		apperror.NotFound,
+	)
+}
+
+// Requeue moves a dead job back to the queue with fresh attempts, keeping its error history.
+func (r job) Requeue(ctx context.Context, id string) (entity.Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	primitiveObjectID, err := objectID(id, "Job")
+
+	if err != nil {
+		return entity.Job{}, err
+	}
+
+	filter := bson.D{{Key: "_id", Value: primitiveObjectID}}
+
+	var job entity.Job
+	err = r.mongoDBAdapter.FindOne(ctx, r.deadLetterCollection, &job, filter)
+
+	if err != nil {
+		return entity.Job{}, mongoError(err, "Dead job")
+	}
+
+	now := time.Now()
+	job.State = entity.JobStatePending
+	job.Attempts = 0
+	job.RunAt = now
+	job.UpdatedAt = now
+	job.DeadAt = nil
+
+	err = r.mongoDBAdapter.WithTransaction(ctx, func(ctx context.Context) error {
+		isSuccess, err := r.mongoDBAdapter.DeleteOne(ctx, r.deadLetterCollection, filter)
+		if err != nil {
+			return err
+		}
+		if !isSuccess {
+			// A concurrent requeue got there first.
+			return apperror.NewError(
+				"Dead job not found",
+				"Dead job not found",
+				apperror.NotFound,
+			)
+		}
+
+		_, err = r.mongoDBAdapter.InsertOne(ctx, r.jobCollection, job)
+		return err
+	})
+
+	if err != nil {
+		return entity.Job{}, mongoError(err, "Job")
+	}
+
+	return job, nil
+}
+
+// PurgeCompleted deletes completed jobs that finished before the cutoff.
+func (r job) PurgeCompleted(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	deleted, err := r.mongoDBAdapter.DeleteMany(ctx, r.jobCollection, bson.D{
+		{Key: "state", Value: entity.JobStateCompleted},
+		{Key: "completed_at", Value: bson.D{{Key: "$lt", Value: before}}},
+	})
+
+	if err != nil {
+		return 0, mongoError(err, "Job")
+	}
+
+	return deleted, nil
+}
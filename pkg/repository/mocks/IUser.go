@@ -3,8 +3,17 @@
 package mocks
 
 import (
-	mock "github.com/stretchr/testify/mock"
+	context "context"
+
+	adapter "github.com/wisesight/go-api-template/pkg/adapter"
+
 	entity "github.com/wisesight/go-api-template/pkg/entity"
+
+	query "github.com/wisesight/go-api-template/pkg/query"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // IUser is an autogenerated mock type for the IUser type
@@ -12,20 +21,43 @@ type IUser struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: user
-func (_m *IUser) Create(user *entity.User) (string, error) {
-	ret := _m.Called(user)
+// Create provides a mock function with given fields: ctx, user
+func (_m *IUser) Create(ctx context.Context, user *entity.User) (string, error) {
+	ret := _m.Called(ctx, user)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(*entity.User) string); ok {
-		r0 = rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.User) string); ok {
+		r0 = rf(ctx, user)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*entity.User) error); ok {
-		r1 = rf(user)
+	if rf, ok := ret.Get(1).(func(context.Context, *entity.User) error); ok {
+		r1 = rf(ctx, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMany provides a mock function with given fields: ctx, users
+func (_m *IUser) CreateMany(ctx context.Context, users []entity.User) ([]string, error) {
+	ret := _m.Called(ctx, users)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, []entity.User) []string); ok {
+		r0 = rf(ctx, users)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []entity.User) error); ok {
+		r1 = rf(ctx, users)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -33,13 +65,27 @@ func (_m *IUser) Create(user *entity.User) (string, error) {
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *IUser) Delete(id string) error {
-	ret := _m.Called(id)
+// Delete provides a mock function with given fields: ctx, id
+func (_m *IUser) Delete(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Each provides a mock function with given fields: ctx, q, fn
+func (_m *IUser) Each(ctx context.Context, q query.Query, fn func(entity.User) error) error {
+	ret := _m.Called(ctx, q, fn)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, query.Query, func(entity.User) error) error); ok {
+		r0 = rf(ctx, q, fn)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -47,13 +93,85 @@ func (_m *IUser) Delete(id string) error {
 	return r0
 }
 
-// GetAll provides a mock function with given fields:
-func (_m *IUser) GetAll() ([]entity.User, error) {
-	ret := _m.Called()
+// GetAll provides a mock function with given fields: ctx, q, pagination
+func (_m *IUser) GetAll(ctx context.Context, q query.Query, pagination adapter.Pagination) ([]entity.User, adapter.PageInfo, error) {
+	ret := _m.Called(ctx, q, pagination)
+
+	var r0 []entity.User
+	if rf, ok := ret.Get(0).(func(context.Context, query.Query, adapter.Pagination) []entity.User); ok {
+		r0 = rf(ctx, q, pagination)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.User)
+		}
+	}
+
+	var r1 adapter.PageInfo
+	if rf, ok := ret.Get(1).(func(context.Context, query.Query, adapter.Pagination) adapter.PageInfo); ok {
+		r1 = rf(ctx, q, pagination)
+	} else {
+		r1 = ret.Get(1).(adapter.PageInfo)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, query.Query, adapter.Pagination) error); ok {
+		r2 = rf(ctx, q, pagination)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetByID provides a mock function with given fields: ctx, id
+func (_m *IUser) GetByID(ctx context.Context, id string) (entity.User, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 entity.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) entity.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(entity.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByUsername provides a mock function with given fields: ctx, username
+func (_m *IUser) GetByUsername(ctx context.Context, username string) (entity.User, error) {
+	ret := _m.Called(ctx, username)
+
+	var r0 entity.User
+	if rf, ok := ret.Get(0).(func(context.Context, string) entity.User); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(entity.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByUsernames provides a mock function with given fields: ctx, usernames
+func (_m *IUser) GetByUsernames(ctx context.Context, usernames []string) ([]entity.User, error) {
+	ret := _m.Called(ctx, usernames)
 
 	var r0 []entity.User
-	if rf, ok := ret.Get(0).(func() []entity.User); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []entity.User); ok {
+		r0 = rf(ctx, usernames)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]entity.User)
@@ -61,8 +179,8 @@ func (_m *IUser) GetAll() ([]entity.User, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, usernames)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -70,20 +188,20 @@ func (_m *IUser) GetAll() ([]entity.User, error) {
 	return r0, r1
 }
 
-// GetByID provides a mock function with given fields: id
-func (_m *IUser) GetByID(id string) (entity.User, error) {
-	ret := _m.Called(id)
+// GetDeletedByID provides a mock function with given fields: ctx, id
+func (_m *IUser) GetDeletedByID(ctx context.Context, id string) (entity.User, error) {
+	ret := _m.Called(ctx, id)
 
 	var r0 entity.User
-	if rf, ok := ret.Get(0).(func(string) entity.User); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) entity.User); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Get(0).(entity.User)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -91,20 +209,76 @@ func (_m *IUser) GetByID(id string) (entity.User, error) {
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: id, user
-func (_m *IUser) Update(id string, user *entity.User) (bool, error) {
-	ret := _m.Called(id, user)
+// Patch provides a mock function with given fields: ctx, id, version, set, unset
+func (_m *IUser) Patch(ctx context.Context, id string, version int64, set map[string]interface{}, unset []string) (entity.User, error) {
+	ret := _m.Called(ctx, id, version, set, unset)
+
+	var r0 entity.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, map[string]interface{}, []string) entity.User); ok {
+		r0 = rf(ctx, id, version, set, unset)
+	} else {
+		r0 = ret.Get(0).(entity.User)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, map[string]interface{}, []string) error); ok {
+		r1 = rf(ctx, id, version, set, unset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeDeleted provides a mock function with given fields: ctx, before
+func (_m *IUser) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *IUser) Restore(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, id, user
+func (_m *IUser) Update(ctx context.Context, id string, user *entity.User) (bool, error) {
+	ret := _m.Called(ctx, id, user)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(string, *entity.User) bool); ok {
-		r0 = rf(id, user)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *entity.User) bool); ok {
+		r0 = rf(ctx, id, user)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, *entity.User) error); ok {
-		r1 = rf(id, user)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *entity.User) error); ok {
+		r1 = rf(ctx, id, user)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -112,6 +286,20 @@ func (_m *IUser) Update(id string, user *entity.User) (bool, error) {
 	return r0, r1
 }
 
+// UpdatePassword provides a mock function with given fields: ctx, id, hash
+func (_m *IUser) UpdatePassword(ctx context.Context, id string, hash string) error {
+	ret := _m.Called(ctx, id, hash)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, id, hash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewIUser interface {
 	mock.TestingT
 	Cleanup(func())
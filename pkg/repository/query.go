@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"regexp"
+
+	"github.com/wisesight/go-api-template/pkg/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// queryOperators maps query operators to MongoDB ones. Prefix is matched with an anchored regex instead.
+var queryOperators = map[query.Operator]string{
+	query.Equal:          "$eq",
+	query.NotEqual:       "$ne",
+	query.GreaterThan:    "$gt",
+	query.GreaterOrEqual: "$gte",
+	query.LessThan:       "$lt",
+	query.LessOrEqual:    "$lte",
+	query.In:             "$in",
+}
+
+// queryFilter builds the MongoDB filter of q. Values are always compared as literals: regexes are quoted and every
+// other value goes through an explicit operator, so a value can never be read as an operator itself.
+func queryFilter(q query.Query) bson.D {
+	conditions := bson.A{}
+	for _, condition := range q.Conditions {
+		if condition.Operator == query.Prefix {
+			prefix, _ := condition.Value.(string)
+			conditions = append(conditions, bson.D{{Key: condition.Path, Value: primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefix)}}})
+			continue
+		}
+
+		operator, ok := queryOperators[condition.Operator]
+		if !ok {
+			continue
+		}
+		conditions = append(conditions, bson.D{{Key: condition.Path, Value: bson.D{{Key: operator, Value: condition.Value}}}})
+	}
+
+	if q.Text != "" && len(q.TextFields) > 0 {
+		text := bson.A{}
+		for _, field := range q.TextFields {
+			text = append(text, bson.D{{Key: field, Value: primitive.Regex{Pattern: regexp.QuoteMeta(q.Text), Options: "i"}}})
+		}
+		conditions = append(conditions, bson.D{{Key: "$or", Value: text}})
+	}
+
+	if len(conditions) == 0 {
+		return bson.D{}
+	}
+	return bson.D{{Key: "$and", Value: conditions}}
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type QueryFilterSuite struct {
+	suite.Suite
+}
+
+func TestQueryFilterSuite(t *testing.T) {
+	suite.Run(t, new(QueryFilterSuite))
+}
+
+func (s *QueryFilterSuite) TestQueryFilter() {
+
+	s.Run("should match everything for an empty query", func() {
+		s.Equal(bson.D{}, queryFilter(query.Query{}))
+	})
+
+	s.Run("should combine every condition and the free text", func() {
+		from := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		filter := queryFilter(query.Query{
+			Conditions: []query.Condition{
+				{Path: "birth_date", Operator: query.GreaterOrEqual, Value: from},
+				{Path: "name", Operator: query.Prefix, Value: "j.o"},
+				{Path: "username", Operator: query.In, Value: []interface{}{"a", "b"}},
+			},
+			Text:       "john (admin)",
+			TextFields: []string{"name", "username"},
+		})
+
+		s.Equal(bson.D{{Key: "$and", Value: bson.A{
+			bson.D{{Key: "birth_date", Value: bson.D{{Key: "$gte", Value: from}}}},
+			bson.D{{Key: "name", Value: primitive.Regex{Pattern: `^j\.o`}}},
+			bson.D{{Key: "username", Value: bson.D{{Key: "$in", Value: []interface{}{"a", "b"}}}}},
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "name", Value: primitive.Regex{Pattern: `john \(admin\)`, Options: "i"}}},
+				bson.D{{Key: "username", Value: primitive.Regex{Pattern: `john \(admin\)`, Options: "i"}}},
+			}}},
+		}}}, filter)
+	})
+
+	s.Run("should compare values that look like operators as literals", func() {
+		filter := queryFilter(query.Query{
+			Conditions: []query.Condition{{Path: "username", Operator: query.Equal, Value: `{"$ne":null}`}},
+		})
+
+		s.Equal(bson.D{{Key: "$and", Value: bson.A{
+			bson.D{{Key: "username", Value: bson.D{{Key: "$eq", Value: `{"$ne":null}`}}}},
+		}}}, filter)
+	})
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+)
+
+// ITransactor lets usecases make several repository calls atomic. Repositories called with the ctx passed to fn
+// take part in the transaction.
+type ITransactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactor struct {
+	mongoDBAdapter adapter.IMongoDBAdapter
+}
+
+func NewTransactor(mongoDBAdapter adapter.IMongoDBAdapter) ITransactor {
+	return &transactor{
+		mongoDBAdapter: mongoDBAdapter,
+	}
+}
+
+func (t transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.mongoDBAdapter.WithTransaction(ctx, fn)
+}
@@ -2,21 +2,58 @@ package repository
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/wisesight/go-api-template/pkg/adapter"
-	"github.com/wisesight/go-api-template/pkg/apperror"
 	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/query"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type IUser interface {
-	GetAll() ([]entity.User, error)
-	GetByID(id string) (entity.User, error)
-	Create(user *entity.User) (string, error)
-	Update(id string, user *entity.User) (bool, error)
-	Delete(id string) error
+	// GetAll pages through the users matching q.
+	GetAll(ctx context.Context, q query.Query, pagination adapter.Pagination) ([]entity.User, adapter.PageInfo, error)
+	GetByID(ctx context.Context, id string) (entity.User, error)
+	GetByUsername(ctx context.Context, username string) (entity.User, error)
+	// GetByUsernames returns the users with any of the given usernames, deleted ones included as they keep their
+	// username until purged.
+	GetByUsernames(ctx context.Context, usernames []string) ([]entity.User, error)
+	// Each calls fn with every user matching q, streaming them from the database.
+	Each(ctx context.Context, q query.Query, fn func(user entity.User) error) error
+	Create(ctx context.Context, user *entity.User) (string, error)
+	// CreateMany creates users in one transaction and sets their IDs. Either every user is created or none is, and
+	// the users that cannot be inserted fail it with WriteErrors.
+	CreateMany(ctx context.Context, users []entity.User) ([]string, error)
+	// Update applies user only while the stored user is still at user.Version, then increments user.Version.
+	Update(ctx context.Context, id string, user *entity.User) (bool, error)
+	// Patch sets and removes the given fields only while the stored user is still at version, increments the
+	// version and returns the patched user.
+	Patch(ctx context.Context, id string, version int64, set map[string]interface{}, unset []string) (entity.User, error)
+	// UpdatePassword replaces the stored password hash without changing the user's version.
+	UpdatePassword(ctx context.Context, id string, hash string) error
+	// Delete soft deletes a user. Deleted users are hidden until restored or purged.
+	Delete(ctx context.Context, id string) error
+	// GetDeletedByID returns a deleted user, failing with apperror.NotFound unless the user is deleted.
+	GetDeletedByID(ctx context.Context, id string) (entity.User, error)
+	Restore(ctx context.Context, id string) error
+	// PurgeDeleted removes users deleted before the cutoff for good.
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+}
+
+// UserIndexes make usernames unique. Documents without a username are left out of the index.
+var UserIndexes = []adapter.Index{
+	{
+		Name:          "username_unique",
+		Keys:          []adapter.IndexKey{{Field: "username"}},
+		Unique:        true,
+		PartialFilter: bson.D{{Key: "username", Value: bson.D{{Key: "$type", Value: "string"}}}},
+	},
+	{
+		Name:          "deleted_at",
+		Keys:          []adapter.IndexKey{{Field: "deleted_at"}},
+		PartialFilter: bson.D{{Key: "deleted_at", Value: bson.D{{Key: "$exists", Value: true}}}},
+	},
 }
 
 type UserConfig struct {
@@ -24,27 +61,51 @@ type UserConfig struct {
 }
 
 type user struct {
+	base           IRepository[entity.User]
 	mongoDBAdapter adapter.IMongoDBAdapter
-	userCollection adapter.IMongoCollection
+	outbox         adapter.IOutbox
 	timeout        time.Duration
 }
 
-func NewUser(userConfig UserConfig, mongoDBAdapter adapter.IMongoDBAdapter, userCollection adapter.IMongoCollection) IUser {
+func NewUser(userConfig UserConfig, mongoDBAdapter adapter.IMongoDBAdapter, userCollection adapter.IMongoCollection, outbox adapter.IOutbox) IUser {
 
 	return &user{
+		base: NewRepository[entity.User](RepositoryConfig{
+			Timeout:    userConfig.Timeout,
+			EntityName: "User",
+			// Passwords are not changed by updates.
+			CreateOnlyFields: []string{"password"},
+			SoftDelete:       true,
+		}, mongoDBAdapter, userCollection),
 		mongoDBAdapter: mongoDBAdapter,
-		userCollection: userCollection,
+		outbox:         outbox,
 		timeout:        userConfig.Timeout,
 	}
 }
 
-func (r user) GetAll() ([]entity.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
-	defer cancel()
+// GetAll builds the filter of q with queryFilter and passes it to IMongoDBAdapter.FindPage, the paginated Find,
+// as users are listed a page at a time.
+func (r user) GetAll(ctx context.Context, q query.Query, pagination adapter.Pagination) ([]entity.User, adapter.PageInfo, error) {
+	return r.base.Find(ctx, queryFilter(q), pagination)
+}
+
+func (r user) GetByID(ctx context.Context, id string) (entity.User, error) {
+	return r.base.GetByID(ctx, id)
+}
 
-	var users []entity.User
+func (r user) GetByUsername(ctx context.Context, username string) (entity.User, error) {
+	return r.base.FindOne(ctx, bson.D{{Key: "username", Value: username}})
+}
 
-	err := r.mongoDBAdapter.Find(ctx, r.userCollection, &users, bson.D{})
+func (r user) GetByUsernames(ctx context.Context, usernames []string) ([]entity.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	users := []entity.User{}
+	err := r.base.EachWithDeleted(ctx, bson.D{{Key: "username", Value: bson.D{{Key: "$in", Value: usernames}}}}, func(user entity.User) error {
+		users = append(users, user)
+		return nil
+	})
 
 	if err != nil {
 		return nil, err
@@ -53,95 +114,156 @@ func (r user) GetAll() ([]entity.User, error) {
 	return users, nil
 }
 
-func (r user) GetByID(id string) (entity.User, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
-	defer cancel()
+func (r user) Each(ctx context.Context, q query.Query, fn func(user entity.User) error) error {
+	return r.base.Each(ctx, queryFilter(q), fn)
+}
 
-	primitiveObjectID, err := primitive.ObjectIDFromHex(id)
+func (r user) Create(ctx context.Context, user *entity.User) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
 
-	if err != nil {
-		return entity.User{}, err
-	}
+	var id string
+	err := r.mongoDBAdapter.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = r.base.Create(ctx, user)
 
-	var user entity.User
+		if err != nil {
+			return err
+		}
 
-	err = r.mongoDBAdapter.FindOne(ctx, r.userCollection, &user, bson.D{{Key: "_id", Value: primitiveObjectID}})
+		return r.outbox.Add(ctx, userEvent(entity.EventUserCreated, id, user))
+	})
 
 	if err != nil {
-		// if err == adapter.ErrNoDocuments {
-		// return entity.User{}, ErrUserNotFound
-		// }
-		return entity.User{}, err
+		return "", mongoError(err, "User")
 	}
 
-	return user, nil
+	user.ID = id
+
+	return id, nil
 }
 
-func (r user) Create(user *entity.User) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+func (r user) CreateMany(ctx context.Context, users []entity.User) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	// validate
+	var ids []string
+	err := r.mongoDBAdapter.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		ids, err = r.base.CreateMany(ctx, users)
 
-	primitiveObjectID, err := r.mongoDBAdapter.InsertOne(ctx, r.userCollection, user)
+		if err != nil {
+			return err
+		}
+
+		for i, id := range ids {
+			if err := r.outbox.Add(ctx, userEvent(entity.EventUserCreated, id, &users[i])); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 
 	if err != nil {
-		return "", err
+		return nil, mongoError(err, "User")
 	}
 
-	return primitiveObjectID.Hex(), nil
+	for i, id := range ids {
+		users[i].ID = id
+	}
+
+	return ids, nil
 }
 
-func (r user) Update(id string, user *entity.User) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+func (r user) Update(ctx context.Context, id string, user *entity.User) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	primitiveObjectID, err := primitive.ObjectIDFromHex(id)
+	err := r.mongoDBAdapter.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := r.base.UpdateVersion(ctx, id, user.Version, user); err != nil {
+			return err
+		}
 
-	if err != nil {
-		return false, err
-	}
+		event := userEvent(entity.EventUserUpdated, id, user)
+		event.Payload["version"] = user.Version + 1
 
-	isSuccess, err := r.mongoDBAdapter.UpdateOne(ctx, r.userCollection, bson.D{{Key: "_id", Value: primitiveObjectID}}, bson.D{{Key: "$set", Value: user}})
+		return r.outbox.Add(ctx, event)
+	})
 
 	if err != nil {
-		return false, err
+		return false, mongoError(err, "User")
 	}
 
-	if !isSuccess {
-		return false, apperror.NewError(
-			"User not found",
-			"User not found",
-			apperror.NotFound,
-		)
-	}
+	user.Version++
 
 	return true, nil
 }
 
-func (r user) Delete(id string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+func (r user) Patch(ctx context.Context, id string, version int64, set map[string]interface{}, unset []string) (entity.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	primitiveObjectID, err := primitive.ObjectIDFromHex(id)
-
-	if err != nil {
-		return err
+	fields := bson.D{}
+	for key, value := range set {
+		fields = append(fields, bson.E{Key: key, Value: value})
 	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+
+	var patched entity.User
+	err := r.mongoDBAdapter.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := r.base.PatchVersion(ctx, id, version, fields, unset); err != nil {
+			return err
+		}
 
-	isSuccess, err := r.mongoDBAdapter.DeleteOne(ctx, r.userCollection, bson.D{{Key: "_id", Value: primitiveObjectID}})
+		var err error
+		patched, err = r.base.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		event := userEvent(entity.EventUserUpdated, id, &patched)
+		event.Payload["version"] = patched.Version
+
+		return r.outbox.Add(ctx, event)
+	})
 
 	if err != nil {
-		return err
+		return entity.User{}, mongoError(err, "User")
 	}
 
-	if !isSuccess {
-		return apperror.NewError(
-			"User not found",
-			"User not found",
-			apperror.NotFound,
-		)
-	}
+	return patched, nil
+}
+
+func (r user) UpdatePassword(ctx context.Context, id string, hash string) error {
+	return r.base.SetFields(ctx, id, bson.D{{Key: "password", Value: hash}})
+}
+
+func (r user) Delete(ctx context.Context, id string) error {
+	return r.base.Delete(ctx, id)
+}
+
+func (r user) GetDeletedByID(ctx context.Context, id string) (entity.User, error) {
+	return r.base.GetDeletedByID(ctx, id)
+}
 
-	return nil
+func (r user) Restore(ctx context.Context, id string) error {
+	return r.base.Restore(ctx, id)
+}
+
+func (r user) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	return r.base.Purge(ctx, before)
+}
+
+// userEvent builds an outbox event for a user write. The password is never part of the payload.
+func userEvent(eventType string, id string, user *entity.User) *entity.OutboxEvent {
+	return &entity.OutboxEvent{
+		Type:        eventType,
+		AggregateID: id,
+		Payload: map[string]interface{}{
+			"id":       id,
+			"name":     user.Name,
+			"username": user.Username,
+		},
+	}
 }
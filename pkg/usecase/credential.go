@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/password"
+	"github.com/wisesight/go-api-template/pkg/repository"
+)
+
+type ICredential interface {
+	// Authenticate returns the user with the given username and password, failing with apperror.Unauthenticated
+	// when there is none. A password hashed with outdated parameters is rehashed on the way.
+	Authenticate(ctx context.Context, username string, password string) (entity.User, error)
+}
+
+type credential struct {
+	repo   repository.IUser
+	hasher password.IHasher
+}
+
+func NewCredential(repo repository.IUser, hasher password.IHasher) ICredential {
+	return &credential{
+		repo,
+		hasher,
+	}
+}
+
+func (u credential) Authenticate(ctx context.Context, username string, password string) (entity.User, error) {
+	user, err := u.repo.GetByUsername(ctx, username)
+
+	var appErr apperror.AppError
+	if errors.As(err, &appErr) && appErr.Code == apperror.NotFound {
+		// Hash anyway, so unknown usernames take as long as wrong passwords.
+		u.hasher.Hash(password)
+		return entity.User{}, invalidCredentialsError()
+	}
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	ok, err := u.hasher.Verify(user.Password, password)
+	if err != nil {
+		return entity.User{}, err
+	}
+	if !ok {
+		return entity.User{}, invalidCredentialsError()
+	}
+
+	if u.hasher.NeedsRehash(user.Password) {
+		// A failed rehash is not worth failing the login; it is tried again next time.
+		if hash, err := u.hasher.Hash(password); err == nil {
+			if err := u.repo.UpdatePassword(ctx, user.ID, hash); err == nil {
+				user.Password = hash
+			}
+		}
+	}
+
+	return user, nil
+}
+
+func invalidCredentialsError() error {
+	return apperror.NewError(
+		"Invalid username or password",
+		"Invalid username or password",
+		apperror.Unauthenticated,
+	)
+}
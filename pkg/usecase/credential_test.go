@@ -0,0 +1,123 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/password"
+	"github.com/wisesight/go-api-template/pkg/repository/mocks"
+	"github.com/wisesight/go-api-template/pkg/usecase"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type CredentialUsecaseSuite struct {
+	suite.Suite
+	ctx               context.Context
+	userRepo          *mocks.IUser
+	hasher            password.IHasher
+	credentialUseCase usecase.ICredential
+
+	resUserRepoGetByUsername entity.User
+	errUserRepoGetByUsername error
+
+	errUserRepoUpdatePassword error
+}
+
+func (s *CredentialUsecaseSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.userRepo = &mocks.IUser{}
+	var err error
+	s.hasher, err = password.NewBcryptHasher(password.HasherConfig{Cost: bcrypt.MinCost})
+	s.Require().NoError(err)
+	s.credentialUseCase = usecase.NewCredential(s.userRepo, s.hasher)
+
+	hash, err := s.hasher.Hash("A1b2C3d$")
+	s.NoError(err)
+	s.resUserRepoGetByUsername = entity.User{ID: "mock-id", Username: "johndoe", Password: hash}
+	s.errUserRepoGetByUsername = nil
+	s.errUserRepoUpdatePassword = nil
+
+	s.userRepo.On("GetByUsername", mock.Anything, mock.Anything).Return(
+		func(context.Context, string) entity.User {
+			return s.resUserRepoGetByUsername
+		},
+		func(context.Context, string) error {
+			return s.errUserRepoGetByUsername
+		},
+	)
+
+	s.userRepo.On("UpdatePassword", mock.Anything, mock.Anything, mock.Anything).Return(
+		func(context.Context, string, string) error {
+			return s.errUserRepoUpdatePassword
+		},
+	)
+}
+
+func TestCredentialUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(CredentialUsecaseSuite))
+}
+
+func (s *CredentialUsecaseSuite) TestAuthenticate() {
+
+	s.Run("should return user when password matches", func() {
+		user, err := s.credentialUseCase.Authenticate(s.ctx, "johndoe", "A1b2C3d$")
+
+		s.NoError(err)
+		s.Equal("mock-id", user.ID)
+		s.userRepo.AssertNotCalled(s.T(), "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	s.Run("should return unauthenticated when password does not match", func() {
+		_, err := s.credentialUseCase.Authenticate(s.ctx, "johndoe", "wrong")
+
+		var appErr apperror.AppError
+		s.True(errors.As(err, &appErr))
+		s.Equal(apperror.Unauthenticated, appErr.Code)
+	})
+
+	s.Run("should return unauthenticated when user not found", func() {
+		s.errUserRepoGetByUsername = apperror.NewError("User not found", "User not found", apperror.NotFound)
+
+		_, err := s.credentialUseCase.Authenticate(s.ctx, "nobody", "A1b2C3d$")
+
+		var appErr apperror.AppError
+		s.True(errors.As(err, &appErr))
+		s.Equal(apperror.Unauthenticated, appErr.Code)
+	})
+}
+
+// otherHasher hashes with another cost than the usecase.
+func (s *CredentialUsecaseSuite) otherHasher() password.IHasher {
+	hasher, err := password.NewBcryptHasher(password.HasherConfig{Cost: bcrypt.MinCost + 1})
+	s.Require().NoError(err)
+	return hasher
+}
+
+func (s *CredentialUsecaseSuite) TestRehash() {
+
+	s.Run("should rehash a password hashed with another cost", func() {
+		hash, _ := s.otherHasher().Hash("A1b2C3d$")
+		s.resUserRepoGetByUsername.Password = hash
+
+		user, err := s.credentialUseCase.Authenticate(s.ctx, "johndoe", "A1b2C3d$")
+
+		s.NoError(err)
+		s.False(s.hasher.NeedsRehash(user.Password))
+		s.userRepo.AssertCalled(s.T(), "UpdatePassword", s.ctx, "mock-id", user.Password)
+	})
+
+	s.Run("should still log in when the rehash cannot be stored", func() {
+		hash, _ := s.otherHasher().Hash("A1b2C3d$")
+		s.resUserRepoGetByUsername.Password = hash
+		s.errUserRepoUpdatePassword = errors.New("update failed")
+
+		_, err := s.credentialUseCase.Authenticate(s.ctx, "johndoe", "A1b2C3d$")
+
+		s.NoError(err)
+	})
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/repository"
+)
+
+type IJob interface {
+	List(ctx context.Context, filter repository.JobFilter, page int64, limit int64) ([]entity.Job, int64, error)
+	GetByID(ctx context.Context, id string) (entity.Job, error)
+	Requeue(ctx context.Context, id string) (entity.Job, error)
+	PurgeCompleted(ctx context.Context, before time.Time) (int64, error)
+}
+
+type job struct {
+	repo repository.IJob
+}
+
+func NewJob(repo repository.IJob) IJob {
+	return &job{
+		repo,
+	}
+}
+
+func (u job) List(ctx context.Context, filter repository.JobFilter, page int64, limit int64) ([]entity.Job, int64, error) {
+	jobs, total, err := u.repo.List(ctx, filter, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+func (u job) GetByID(ctx context.Context, id string) (entity.Job, error) {
+	job, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return entity.Job{}, err
+	}
+	return job, nil
+}
+
+func (u job) Requeue(ctx context.Context, id string) (entity.Job, error) {
+	job, err := u.repo.Requeue(ctx, id)
+	if err != nil {
+		return entity.Job{}, err
+	}
+	return job, nil
+}
+
+func (u job) PurgeCompleted(ctx context.Context, before time.Time) (int64, error) {
+	deleted, err := u.repo.PurgeCompleted(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
@@ -0,0 +1,153 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/repository"
+	"github.com/wisesight/go-api-template/pkg/repository/mocks"
+	"github.com/wisesight/go-api-template/pkg/usecase"
+)
+
+type JobUsecaseSuite struct {
+	suite.Suite
+	ctx        context.Context
+	jobRepo    *mocks.IJob
+	jobUseCase usecase.IJob
+
+	resJobRepoList   []entity.Job
+	totalJobRepoList int64
+	errJobRepoList   error
+
+	resJobRepoRequeue entity.Job
+	errJobRepoRequeue error
+
+	resJobRepoPurgeCompleted int64
+	errJobRepoPurgeCompleted error
+}
+
+func (s *JobUsecaseSuite) SetupSuite() {
+	s.ctx = context.WithValue(context.Background(), log.RequestIDKey, "mock-request-id")
+	s.jobRepo = &mocks.IJob{}
+	s.jobUseCase = usecase.NewJob(s.jobRepo)
+
+	s.jobRepo.On("List", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(context.Context, repository.JobFilter, int64, int64) []entity.Job {
+			return s.resJobRepoList
+		},
+		func(context.Context, repository.JobFilter, int64, int64) int64 {
+			return s.totalJobRepoList
+		},
+		func(context.Context, repository.JobFilter, int64, int64) error {
+			return s.errJobRepoList
+		},
+	)
+
+	s.jobRepo.On("Requeue", mock.Anything, mock.Anything).Return(
+		func(context.Context, string) entity.Job {
+			return s.resJobRepoRequeue
+		},
+		func(context.Context, string) error {
+			return s.errJobRepoRequeue
+		},
+	)
+
+	s.jobRepo.On("PurgeCompleted", mock.Anything, mock.Anything).Return(
+		func(context.Context, time.Time) int64 {
+			return s.resJobRepoPurgeCompleted
+		},
+		func(context.Context, time.Time) error {
+			return s.errJobRepoPurgeCompleted
+		},
+	)
+}
+
+func TestJobUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(JobUsecaseSuite))
+}
+
+func (s *JobUsecaseSuite) SetupTest() {
+	s.resJobRepoList = []entity.Job{}
+	s.totalJobRepoList = 0
+	s.errJobRepoList = nil
+
+	s.resJobRepoRequeue = entity.Job{}
+	s.errJobRepoRequeue = nil
+
+	s.resJobRepoPurgeCompleted = 0
+	s.errJobRepoPurgeCompleted = nil
+}
+
+func (s *JobUsecaseSuite) TestList() {
+
+	s.Run("should list jobs with the given filter and page", func() {
+		filter := repository.JobFilter{State: entity.JobStateDead, Type: "email"}
+
+		s.jobUseCase.List(s.ctx, filter, 2, 20)
+
+		s.jobRepo.AssertCalled(s.T(), "List", s.ctx, filter, int64(2), int64(20))
+	})
+
+	s.Run("should return error when list failed", func() {
+		s.errJobRepoList = errors.New("list failed")
+
+		res, total, err := s.jobUseCase.List(s.ctx, repository.JobFilter{}, 1, 20)
+
+		s.Nil(res)
+		s.Zero(total)
+		s.EqualError(err, "list failed")
+	})
+
+	s.Run("should return jobs and total when list success", func() {
+		s.resJobRepoList = []entity.Job{{Type: "email"}}
+		s.totalJobRepoList = 21
+		s.errJobRepoList = nil
+
+		res, total, err := s.jobUseCase.List(s.ctx, repository.JobFilter{}, 1, 20)
+
+		s.Equal(s.resJobRepoList, res)
+		s.Equal(int64(21), total)
+		s.Nil(err)
+	})
+}
+
+func (s *JobUsecaseSuite) TestRequeue() {
+
+	s.Run("should return error when requeue failed", func() {
+		s.errJobRepoRequeue = errors.New("requeue failed")
+
+		_, err := s.jobUseCase.Requeue(s.ctx, "mock-id")
+
+		s.EqualError(err, "requeue failed")
+	})
+
+	s.Run("should return pending job when requeue success", func() {
+		s.resJobRepoRequeue = entity.Job{State: entity.JobStatePending}
+		s.errJobRepoRequeue = nil
+
+		res, err := s.jobUseCase.Requeue(s.ctx, "mock-id")
+
+		s.Equal(entity.JobStatePending, res.State)
+		s.Nil(err)
+	})
+}
+
+func (s *JobUsecaseSuite) TestPurgeCompleted() {
+
+	s.Run("should return deleted count when purge success", func() {
+		before := time.Now()
+		s.resJobRepoPurgeCompleted = 3
+
+		res, err := s.jobUseCase.PurgeCompleted(s.ctx, before)
+
+		s.jobRepo.AssertCalled(s.T(), "PurgeCompleted", s.ctx, before)
+		s.Equal(int64(3), res)
+		s.Nil(err)
+	})
+}
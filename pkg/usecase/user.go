@@ -1,60 +1,364 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/audit"
 	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/password"
+	"github.com/wisesight/go-api-template/pkg/query"
 	"github.com/wisesight/go-api-template/pkg/repository"
 )
 
+// IUser manages users. Every write is recorded in the audit trail, in the same transaction, with the actor taken
+// from the entity.UserSession in ctx. Passwords are hashed before they are stored.
 type IUser interface {
-	GetAll() ([]entity.User, error)
-	GetByID(id string) (entity.User, error)
-	Create(user *entity.User) (string, error)
-	Update(id string, user *entity.User) (bool, error)
-	Delete(id string) error
+	// GetAll pages through the users matching q.
+	GetAll(ctx context.Context, q query.Query, pagination adapter.Pagination) ([]entity.User, adapter.PageInfo, error)
+	GetByID(ctx context.Context, id string) (entity.User, error)
+	// Create replaces user.Password with its hash and stores the user.
+	Create(ctx context.Context, user *entity.User) (string, error)
+	// CreateMany creates a batch of users like Create, sets their IDs and returns an error for each user, nil for
+	// the ones created. Users whose username is taken, in the database or earlier in the batch, are left out.
+	CreateMany(ctx context.Context, users []entity.User) []error
+	// Export calls fn with every user matching q, streaming them from the database.
+	Export(ctx context.Context, q query.Query, fn func(user entity.User) error) error
+	Update(ctx context.Context, id string, user *entity.User) (bool, error)
+	// Patch sets and removes the given fields of a user still at version and returns the patched user.
+	Patch(ctx context.Context, id string, version int64, set map[string]interface{}, unset []string) (entity.User, error)
+	Delete(ctx context.Context, id string) error
+	// Restore undoes a delete and returns the restored user.
+	Restore(ctx context.Context, id string) (entity.User, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	// GetAudit pages through the changes made to a user, newest first.
+	GetAudit(ctx context.Context, id string, pagination adapter.Pagination) ([]entity.AuditRecord, adapter.PageInfo, error)
 }
 
+// userRedactedFields are never written to the audit trail.
+var userRedactedFields = []string{"password"}
+
 type user struct {
-	repo repository.IUser
+	repo       repository.IUser
+	auditRepo  repository.IAudit
+	transactor repository.ITransactor
+	hasher     password.IHasher
 }
 
-func NewUser(repo repository.IUser) IUser {
+func NewUser(repo repository.IUser, auditRepo repository.IAudit, transactor repository.ITransactor, hasher password.IHasher) IUser {
 	return &user{
 		repo,
+		auditRepo,
+		transactor,
+		hasher,
 	}
 }
 
-func (u user) GetAll() ([]entity.User, error) {
-	users, err := u.repo.GetAll()
+func (u user) GetAll(ctx context.Context, q query.Query, pagination adapter.Pagination) ([]entity.User, adapter.PageInfo, error) {
+	users, pageInfo, err := u.repo.GetAll(ctx, q, pagination)
 	if err != nil {
-		return nil, err
+		return nil, adapter.PageInfo{}, err
 	}
-	return users, nil
+	return users, pageInfo, nil
 }
 
-func (u user) GetByID(id string) (entity.User, error) {
-	user, err := u.repo.GetByID(id)
+func (u user) GetByID(ctx context.Context, id string) (entity.User, error) {
+	user, err := u.repo.GetByID(ctx, id)
 	if err != nil {
 		return entity.User{}, err
 	}
 	return user, nil
 }
 
-func (u user) Create(user *entity.User) (string, error) {
-	userID, err := u.repo.Create(user)
+func (u user) Create(ctx context.Context, user *entity.User) (string, error) {
+	hash, err := u.hasher.Hash(user.Password)
+	if err != nil {
+		return "", err
+	}
+	user.Password = hash
+
+	var userID string
+	err = u.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		userID, err = u.repo.Create(ctx, user)
+		if err != nil {
+			return err
+		}
+		return u.record(ctx, entity.AuditActionCreate, userID, nil, user)
+	})
 	if err != nil {
 		return "", err
 	}
 	return userID, nil
 }
 
-func (u user) Update(id string, user *entity.User) (bool, error) {
-	isSuccess, err := u.repo.Update(id, user)
+func (u user) CreateMany(ctx context.Context, users []entity.User) []error {
+	errs := make([]error, len(users))
+	fail := func(indexes []int, err error) []error {
+		for _, i := range indexes {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	pending := []int{}
+	seen := map[string]bool{}
+	for i, user := range users {
+		if seen[user.Username] {
+			errs[i] = usernameTaken()
+			continue
+		}
+		seen[user.Username] = true
+		pending = append(pending, i)
+	}
+
+	usernames := make([]string, len(pending))
+	for j, i := range pending {
+		usernames[j] = users[i].Username
+	}
+	existing, err := u.repo.GetByUsernames(ctx, usernames)
+	if err != nil {
+		return fail(pending, err)
+	}
+	taken := map[string]bool{}
+	for _, user := range existing {
+		taken[user.Username] = true
+	}
+
+	available := pending[:0]
+	for _, i := range pending {
+		if taken[users[i].Username] {
+			errs[i] = usernameTaken()
+		} else {
+			available = append(available, i)
+		}
+	}
+	if len(available) == 0 {
+		return errs
+	}
+
+	batch := make([]entity.User, len(available))
+	for j, i := range available {
+		batch[j] = users[i]
+	}
+	if err := u.hashPasswords(batch); err != nil {
+		return fail(available, err)
+	}
+
+	// A concurrent create can still take a username, failing the whole transaction. The users that failed to insert
+	// are left out and the rest retried, so every retry has fewer users.
+	for len(available) > 0 {
+		err = u.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+			ids, err := u.repo.CreateMany(ctx, batch)
+			if err != nil {
+				return err
+			}
+
+			for j, id := range ids {
+				if err := u.record(ctx, entity.AuditActionCreate, id, nil, batch[j]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			break
+		}
+
+		var writeErrs repository.WriteErrors
+		if !errors.As(err, &writeErrs) || len(writeErrs) == 0 {
+			return fail(available, err)
+		}
+
+		remaining, remainingBatch := []int{}, []entity.User{}
+		for j, i := range available {
+			writeErr, ok := writeErrs[j]
+			if !ok {
+				remaining = append(remaining, i)
+				remainingBatch = append(remainingBatch, batch[j])
+				continue
+			}
+
+			var appErr apperror.AppError
+			if errors.As(writeErr, &appErr) && appErr.Code == apperror.DuplicateKey {
+				writeErr = usernameTaken()
+			}
+			errs[i] = writeErr
+		}
+		available, batch = remaining, remainingBatch
+	}
+
+	for j, i := range available {
+		users[i] = batch[j]
+	}
+	return errs
+}
+
+// hashPasswords replaces the passwords of users with their hashes. Hashing is slow by design, so it is spread over
+// every CPU.
+func (u user) hashPasswords(users []entity.User) error {
+	indexes := make(chan int)
+	errs := make(chan error, len(users))
+
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				hash, err := u.hasher.Hash(users[i].Password)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				users[i].Password = hash
+			}
+		}()
+	}
+
+	for i := range users {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+func (u user) Export(ctx context.Context, q query.Query, fn func(user entity.User) error) error {
+	return u.repo.Each(ctx, q, fn)
+}
+
+func (u user) Update(ctx context.Context, id string, user *entity.User) (bool, error) {
+	// The repository increments user.Version, so a retried transaction must start from the original.
+	version := user.Version
+
+	var isSuccess bool
+	err := u.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		user.Version = version
+
+		before, err := u.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		isSuccess, err = u.repo.Update(ctx, id, user)
+		if err != nil {
+			return err
+		}
+
+		after, err := u.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return u.record(ctx, entity.AuditActionUpdate, id, before, after)
+	})
 	if err != nil {
 		return false, err
 	}
 	return isSuccess, nil
 }
 
-func (u user) Delete(id string) error {
-	return u.repo.Delete(id)
+func (u user) Patch(ctx context.Context, id string, version int64, set map[string]interface{}, unset []string) (entity.User, error) {
+	var after entity.User
+	err := u.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		before, err := u.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		after, err = u.repo.Patch(ctx, id, version, set, unset)
+		if err != nil {
+			return err
+		}
+
+		return u.record(ctx, entity.AuditActionUpdate, id, before, after)
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return after, nil
+}
+
+func (u user) Delete(ctx context.Context, id string) error {
+	return u.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		before, err := u.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := u.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return u.record(ctx, entity.AuditActionDelete, id, before, nil)
+	})
+}
+
+func (u user) Restore(ctx context.Context, id string) (entity.User, error) {
+	var user entity.User
+	err := u.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		before, err := u.repo.GetDeletedByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := u.repo.Restore(ctx, id); err != nil {
+			return err
+		}
+
+		user, err = u.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		return u.record(ctx, entity.AuditActionRestore, id, before, user)
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+func (u user) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	deleted, err := u.repo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func (u user) GetAudit(ctx context.Context, id string, pagination adapter.Pagination) ([]entity.AuditRecord, adapter.PageInfo, error) {
+	records, pageInfo, err := u.auditRepo.List(ctx, entity.AuditEntityUser, id, pagination)
+	if err != nil {
+		return nil, adapter.PageInfo{}, err
+	}
+	return records, pageInfo, nil
+}
+
+func usernameTaken() error {
+	return apperror.NewError("User already exists", "The username is already taken", apperror.DuplicateKey)
+}
+
+// record writes an audit record of a change to a user. before is nil for creates and after for deletes.
+func (u user) record(ctx context.Context, action entity.AuditAction, id string, before, after interface{}) error {
+	actor, _ := entity.UserSessionFromContext(ctx)
+	requestID, _ := ctx.Value(log.RequestIDKey).(string)
+
+	return u.auditRepo.Add(ctx, &entity.AuditRecord{
+		EntityType: entity.AuditEntityUser,
+		EntityID:   id,
+		Action:     action,
+		Actor:      actor,
+		RequestID:  requestID,
+		Changes:    audit.Diff(before, after, userRedactedFields...),
+	})
 }
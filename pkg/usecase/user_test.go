@@ -1,23 +1,36 @@
 package usecase_test
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/apperror"
 	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/password"
+	"github.com/wisesight/go-api-template/pkg/query"
+	"github.com/wisesight/go-api-template/pkg/repository"
 	"github.com/wisesight/go-api-template/pkg/repository/mocks"
 	"github.com/wisesight/go-api-template/pkg/usecase"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UserUsecaseSuite struct {
 	suite.Suite
+	ctx         context.Context
 	userRepo    *mocks.IUser
+	auditRepo   *mocks.IAudit
+	transactor  *mocks.ITransactor
 	userUseCase usecase.IUser
 
-	resUserRepoGetAll []entity.User
-	errUserRepoGetAll error
+	resUserRepoGetAll      []entity.User
+	pageInfoUserRepoGetAll adapter.PageInfo
+	errUserRepoGetAll      error
 
 	resUserRepoGetByID entity.User
 	errUserRepoGetByID error
@@ -25,57 +38,197 @@ type UserUsecaseSuite struct {
 	resUserRepoCreate string
 	errUserRepoCreate error
 
+	resUserRepoGetByUsernames []entity.User
+	errUserRepoGetByUsernames error
+
+	errUserRepoCreateMany error
+	// userRepoCreateManyBatches is the number of users of each CreateMany call.
+	userRepoCreateManyBatches []int
+
+	errUserRepoEach error
+
 	resUserRepoUpdate bool
 	errUserRepoUpdate error
 
+	resUserRepoPatch entity.User
+	errUserRepoPatch error
+
 	errUserRepoDelete error
+
+	resUserRepoGetDeletedByID entity.User
+	errUserRepoGetDeletedByID error
+
+	errUserRepoRestore error
+
+	resUserRepoPurgeDeleted int64
+	errUserRepoPurgeDeleted error
+
+	auditRecords    []*entity.AuditRecord
+	errAuditRepoAdd error
+
+	resAuditRepoList []entity.AuditRecord
+	errAuditRepoList error
 }
 
 func (s *UserUsecaseSuite) SetupSuite() {
+	s.ctx = context.WithValue(context.Background(), log.RequestIDKey, "mock-request-id")
+	s.ctx = entity.ContextWithUserSession(s.ctx, entity.UserSession{UserID: "mock-actor-id", Username: "admin"})
 	s.userRepo = &mocks.IUser{}
-	s.userUseCase = usecase.NewUser(s.userRepo)
+	s.auditRepo = &mocks.IAudit{}
+	s.transactor = &mocks.ITransactor{}
+	hasher, err := password.NewBcryptHasher(password.HasherConfig{Cost: bcrypt.MinCost})
+	s.Require().NoError(err)
+	s.userUseCase = usecase.NewUser(s.userRepo, s.auditRepo, s.transactor, hasher)
+
+	s.transactor.On("WithTransaction", mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		},
+	)
+
+	s.auditRepo.On("Add", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		s.auditRecords = append(s.auditRecords, args.Get(1).(*entity.AuditRecord))
+	}).Return(
+		func(context.Context, *entity.AuditRecord) error {
+			return s.errAuditRepoAdd
+		},
+	)
+
+	s.auditRepo.On("List", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(context.Context, string, string, adapter.Pagination) []entity.AuditRecord {
+			return s.resAuditRepoList
+		},
+		func(context.Context, string, string, adapter.Pagination) adapter.PageInfo {
+			return adapter.PageInfo{}
+		},
+		func(context.Context, string, string, adapter.Pagination) error {
+			return s.errAuditRepoList
+		},
+	)
 
-	s.userRepo.On("GetAll").Return(
-		func() []entity.User {
+	s.userRepo.On("GetAll", mock.Anything, mock.Anything, mock.Anything).Return(
+		func(context.Context, query.Query, adapter.Pagination) []entity.User {
 			return s.resUserRepoGetAll
 		},
-		func() error {
+		func(context.Context, query.Query, adapter.Pagination) adapter.PageInfo {
+			return s.pageInfoUserRepoGetAll
+		},
+		func(context.Context, query.Query, adapter.Pagination) error {
 			return s.errUserRepoGetAll
 		},
 	)
 
-	s.userRepo.On("GetByID", mock.Anything).Return(
-		func(string) entity.User {
+	s.userRepo.On("GetByID", mock.Anything, mock.Anything).Return(
+		func(context.Context, string) entity.User {
 			return s.resUserRepoGetByID
 		},
-		func(string) error {
+		func(context.Context, string) error {
 			return s.errUserRepoGetByID
 		},
 	)
 
-	s.userRepo.On("Create", mock.Anything).Return(
-		func(*entity.User) string {
+	s.userRepo.On("Create", mock.Anything, mock.Anything).Return(
+		func(context.Context, *entity.User) string {
 			return s.resUserRepoCreate
 		},
-		func(*entity.User) error {
+		func(context.Context, *entity.User) error {
 			return s.errUserRepoCreate
 		},
 	)
 
-	s.userRepo.On("Update", mock.Anything, mock.Anything).Return(
-		func(string, *entity.User) bool {
+	s.userRepo.On("GetByUsernames", mock.Anything, mock.Anything).Return(
+		func(context.Context, []string) []entity.User {
+			return s.resUserRepoGetByUsernames
+		},
+		func(context.Context, []string) error {
+			return s.errUserRepoGetByUsernames
+		},
+	)
+
+	s.userRepo.On("CreateMany", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, users []entity.User) []string {
+			if s.errUserRepoCreateMany != nil {
+				return nil
+			}
+			ids := make([]string, len(users))
+			for i := range users {
+				ids[i] = users[i].Username + "-id"
+				users[i].ID = ids[i]
+			}
+			return ids
+		},
+		func(_ context.Context, users []entity.User) error {
+			s.userRepoCreateManyBatches = append(s.userRepoCreateManyBatches, len(users))
+			err := s.errUserRepoCreateMany
+			// Write errors fail only the first attempt, as the users that caused them are left out of the next.
+			if _, ok := err.(repository.WriteErrors); ok {
+				s.errUserRepoCreateMany = nil
+			}
+			return err
+		},
+	)
+
+	s.userRepo.On("Each", mock.Anything, mock.Anything, mock.Anything).Return(
+		func(_ context.Context, _ query.Query, fn func(entity.User) error) error {
+			if s.errUserRepoEach != nil {
+				return s.errUserRepoEach
+			}
+			for _, user := range s.resUserRepoGetAll {
+				if err := fn(user); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+
+	s.userRepo.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(
+		func(context.Context, string, *entity.User) bool {
 			return s.resUserRepoUpdate
 		},
-		func(string, *entity.User) error {
+		func(context.Context, string, *entity.User) error {
 			return s.errUserRepoUpdate
 		},
 	)
 
-	s.userRepo.On("Delete", mock.Anything).Return(
-		func(string) error {
+	s.userRepo.On("Patch", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(context.Context, string, int64, map[string]interface{}, []string) entity.User {
+			return s.resUserRepoPatch
+		},
+		func(context.Context, string, int64, map[string]interface{}, []string) error {
+			return s.errUserRepoPatch
+		},
+	)
+
+	s.userRepo.On("Delete", mock.Anything, mock.Anything).Return(
+		func(context.Context, string) error {
 			return s.errUserRepoDelete
 		},
 	)
+
+	s.userRepo.On("GetDeletedByID", mock.Anything, mock.Anything).Return(
+		func(context.Context, string) entity.User {
+			return s.resUserRepoGetDeletedByID
+		},
+		func(context.Context, string) error {
+			return s.errUserRepoGetDeletedByID
+		},
+	)
+
+	s.userRepo.On("Restore", mock.Anything, mock.Anything).Return(
+		func(context.Context, string) error {
+			return s.errUserRepoRestore
+		},
+	)
+
+	s.userRepo.On("PurgeDeleted", mock.Anything, mock.Anything).Return(
+		func(context.Context, time.Time) int64 {
+			return s.resUserRepoPurgeDeleted
+		},
+		func(context.Context, time.Time) error {
+			return s.errUserRepoPurgeDeleted
+		},
+	)
 }
 
 func TestUserUsecaseSuite(t *testing.T) {
@@ -84,6 +237,7 @@ func TestUserUsecaseSuite(t *testing.T) {
 
 func (s *UserUsecaseSuite) SetupTest() {
 	s.resUserRepoGetAll = []entity.User{}
+	s.pageInfoUserRepoGetAll = adapter.PageInfo{}
 	s.errUserRepoGetAll = nil
 
 	s.resUserRepoGetByID = entity.User{}
@@ -92,24 +246,52 @@ func (s *UserUsecaseSuite) SetupTest() {
 	s.resUserRepoCreate = "id"
 	s.errUserRepoCreate = nil
 
+	s.resUserRepoGetByUsernames = []entity.User{}
+	s.errUserRepoGetByUsernames = nil
+
+	s.errUserRepoCreateMany = nil
+	s.userRepoCreateManyBatches = nil
+
+	s.errUserRepoEach = nil
+
 	s.resUserRepoUpdate = true
 	s.errUserRepoUpdate = nil
 
+	s.resUserRepoPatch = entity.User{}
+	s.errUserRepoPatch = nil
+
 	s.errUserRepoDelete = nil
+
+	s.resUserRepoGetDeletedByID = entity.User{}
+	s.errUserRepoGetDeletedByID = nil
+
+	s.errUserRepoRestore = nil
+
+	s.resUserRepoPurgeDeleted = 0
+	s.errUserRepoPurgeDeleted = nil
+
+	s.auditRecords = nil
+	s.errAuditRepoAdd = nil
+
+	s.resAuditRepoList = nil
+	s.errAuditRepoList = nil
 }
 
 func (s *UserUsecaseSuite) TestGetAll() {
 
-	s.Run("should get all user", func() {
-		s.userUseCase.GetAll()
-		s.userRepo.AssertCalled(s.T(), "GetAll")
+	s.Run("should get all user with the given query and pagination", func() {
+		q := query.Query{Conditions: []query.Condition{{Path: "username", Operator: query.Equal, Value: "user1"}}}
+		pagination := adapter.Pagination{Limit: 10, Cursor: "mock-cursor"}
+
+		s.userUseCase.GetAll(s.ctx, q, pagination)
+		s.userRepo.AssertCalled(s.T(), "GetAll", s.ctx, q, pagination)
 	})
 
 	s.Run("should return error when user failed", func() {
 		s.resUserRepoGetAll = []entity.User{}
 		s.errUserRepoGetAll = errors.New("get all failed")
 
-		res, err := s.userUseCase.GetAll()
+		res, _, err := s.userUseCase.GetAll(s.ctx, query.Query{}, adapter.Pagination{})
 
 		s.Nil(res)
 		s.EqualError(err, "get all failed")
@@ -122,11 +304,13 @@ func (s *UserUsecaseSuite) TestGetAll() {
 				Name: "test",
 			},
 		}
+		s.pageInfoUserRepoGetAll = adapter.PageInfo{NextCursor: "mock-next-cursor", HasMore: true}
 		s.errUserRepoGetAll = nil
 
-		res, err := s.userUseCase.GetAll()
+		res, pageInfo, err := s.userUseCase.GetAll(s.ctx, query.Query{}, adapter.Pagination{})
 
 		s.Equal(res, s.resUserRepoGetAll)
+		s.Equal(s.pageInfoUserRepoGetAll, pageInfo)
 		s.Nil(err)
 	})
 
@@ -137,9 +321,9 @@ func (s *UserUsecaseSuite) TestGetByID() {
 	s.Run("should get user by id", func() {
 		id := "mock-id"
 
-		s.userUseCase.GetByID(id)
+		s.userUseCase.GetByID(s.ctx, id)
 
-		s.userRepo.AssertCalled(s.T(), "GetByID", id)
+		s.userRepo.AssertCalled(s.T(), "GetByID", s.ctx, id)
 	})
 
 	s.Run("should return error when get user by id failed", func() {
@@ -147,7 +331,7 @@ func (s *UserUsecaseSuite) TestGetByID() {
 		s.resUserRepoGetByID = entity.User{}
 		s.errUserRepoGetByID = errors.New("get by id failed")
 
-		res, err := s.userUseCase.GetByID(id)
+		res, err := s.userUseCase.GetByID(s.ctx, id)
 
 		s.Equal(res, entity.User{})
 		s.EqualError(err, "get by id failed")
@@ -161,7 +345,7 @@ func (s *UserUsecaseSuite) TestGetByID() {
 		}
 		s.errUserRepoGetByID = nil
 
-		res, err := s.userUseCase.GetByID(id)
+		res, err := s.userUseCase.GetByID(s.ctx, id)
 
 		s.Equal(res, s.resUserRepoGetByID)
 		s.Nil(err)
@@ -175,9 +359,9 @@ func (s *UserUsecaseSuite) TestCreate() {
 			Name: "test",
 		}
 
-		s.userUseCase.Create(&user)
+		s.userUseCase.Create(s.ctx, &user)
 
-		s.userRepo.AssertCalled(s.T(), "Create", &user)
+		s.userRepo.AssertCalled(s.T(), "Create", s.ctx, &user)
 	})
 
 	s.Run("should return error when create user failed", func() {
@@ -187,7 +371,7 @@ func (s *UserUsecaseSuite) TestCreate() {
 		s.resUserRepoCreate = ""
 		s.errUserRepoCreate = errors.New("create failed")
 
-		_, err := s.userUseCase.Create(&user)
+		_, err := s.userUseCase.Create(s.ctx, &user)
 
 		s.EqualError(err, "create failed")
 	})
@@ -200,13 +384,119 @@ func (s *UserUsecaseSuite) TestCreate() {
 		s.resUserRepoCreate = "mock-id"
 		s.errUserRepoCreate = nil
 
-		res, err := s.userUseCase.Create(&user)
+		res, err := s.userUseCase.Create(s.ctx, &user)
 
 		s.Equal(res, "mock-id")
 		s.Nil(err)
 	})
 }
 
+func (s *UserUsecaseSuite) TestCreateMany() {
+
+	s.Run("should create the users whose username is free", func() {
+		s.resUserRepoGetByUsernames = []entity.User{{Username: "taken"}}
+		s.auditRecords = nil
+		users := []entity.User{
+			{Username: "user1", Password: "A1b2C3d$"},
+			{Username: "taken", Password: "A1b2C3d$"},
+			{Username: "user1", Password: "A1b2C3d$"},
+			{Username: "user2", Password: "A1b2C3d$"},
+		}
+
+		errs := s.userUseCase.CreateMany(s.ctx, users)
+
+		s.Require().Len(errs, 4)
+		s.Nil(errs[0])
+		s.Nil(errs[3])
+		for _, err := range []error{errs[1], errs[2]} {
+			var appErr apperror.AppError
+			s.True(errors.As(err, &appErr))
+			s.Equal(apperror.DuplicateKey, appErr.Code)
+		}
+		s.Equal("user1-id", users[0].ID)
+		s.Equal("user2-id", users[3].ID)
+		s.True(password.IsHashed(users[0].Password))
+		s.Equal("A1b2C3d$", users[1].Password)
+		s.Len(s.auditRecords, 2)
+	})
+
+	s.Run("should not create users whose username a deleted user holds", func() {
+		deletedAt := time.Now()
+		s.resUserRepoGetByUsernames = []entity.User{{Username: "deleted", DeletedAt: &deletedAt}}
+
+		errs := s.userUseCase.CreateMany(s.ctx, []entity.User{{Username: "user1"}, {Username: "deleted"}})
+
+		s.Nil(errs[0])
+		var appErr apperror.AppError
+		s.True(errors.As(errs[1], &appErr))
+		s.Equal(apperror.DuplicateKey, appErr.Code)
+	})
+
+	s.Run("should retry without the users whose insert failed", func() {
+		s.resUserRepoGetByUsernames = []entity.User{}
+		s.userRepoCreateManyBatches = nil
+		s.errUserRepoCreateMany = repository.WriteErrors{
+			1: apperror.NewError("User already exists", "E11000", apperror.DuplicateKey),
+		}
+		users := []entity.User{{Username: "user1"}, {Username: "raced"}, {Username: "user2"}}
+
+		errs := s.userUseCase.CreateMany(s.ctx, users)
+
+		s.Equal([]int{3, 2}, s.userRepoCreateManyBatches)
+		s.Nil(errs[0])
+		s.Nil(errs[2])
+		s.Equal("user1-id", users[0].ID)
+		s.Empty(users[1].ID)
+		s.Equal("user2-id", users[2].ID)
+		var appErr apperror.AppError
+		s.True(errors.As(errs[1], &appErr))
+		s.Equal(apperror.DuplicateKey, appErr.Code)
+	})
+
+	s.Run("should fail every pending user when the insert fails", func() {
+		s.resUserRepoGetByUsernames = []entity.User{}
+		s.errUserRepoCreateMany = errors.New("insert failed")
+
+		errs := s.userUseCase.CreateMany(s.ctx, []entity.User{{Username: "user1"}, {Username: "user2"}})
+
+		s.Len(errs, 2)
+		s.EqualError(errs[0], "insert failed")
+		s.EqualError(errs[1], "insert failed")
+	})
+
+	s.Run("should fail every pending user when usernames cannot be checked", func() {
+		s.errUserRepoGetByUsernames = errors.New("find failed")
+
+		errs := s.userUseCase.CreateMany(s.ctx, []entity.User{{Username: "user1"}})
+
+		s.EqualError(errs[0], "find failed")
+	})
+}
+
+func (s *UserUsecaseSuite) TestExport() {
+
+	s.Run("should stream every matching user", func() {
+		s.resUserRepoGetAll = []entity.User{{Username: "user1"}, {Username: "user2"}}
+
+		var exported []string
+		err := s.userUseCase.Export(s.ctx, query.Query{}, func(user entity.User) error {
+			exported = append(exported, user.Username)
+			return nil
+		})
+
+		s.Nil(err)
+		s.Equal([]string{"user1", "user2"}, exported)
+	})
+
+	s.Run("should return error when export failed", func() {
+		s.errUserRepoEach = errors.New("find failed")
+
+		err := s.userUseCase.Export(s.ctx, query.Query{}, func(entity.User) error { return nil })
+
+		s.EqualError(err, "find failed")
+	})
+}
+
 func (s *UserUsecaseSuite) TestUpdate() {
 
 	s.Run("should update user", func() {
@@ -215,9 +505,9 @@ func (s *UserUsecaseSuite) TestUpdate() {
 			Name: "test",
 		}
 
-		s.userUseCase.Update(id, &user)
+		s.userUseCase.Update(s.ctx, id, &user)
 
-		s.userRepo.AssertCalled(s.T(), "Update", id, &user)
+		s.userRepo.AssertCalled(s.T(), "Update", s.ctx, id, &user)
 	})
 
 	s.Run("should return error when update user failed", func() {
@@ -229,7 +519,7 @@ func (s *UserUsecaseSuite) TestUpdate() {
 		s.resUserRepoUpdate = false
 		s.errUserRepoUpdate = errors.New("update failed")
 
-		res, err := s.userUseCase.Update(id, &user)
+		res, err := s.userUseCase.Update(s.ctx, id, &user)
 
 		s.Equal(res, false)
 		s.EqualError(err, "update failed")
@@ -244,28 +534,67 @@ func (s *UserUsecaseSuite) TestUpdate() {
 		s.resUserRepoUpdate = true
 		s.errUserRepoUpdate = nil
 
-		res, err := s.userUseCase.Update(id, &user)
+		res, err := s.userUseCase.Update(s.ctx, id, &user)
 
 		s.Equal(res, true)
 		s.Nil(err)
 	})
 }
 
+func (s *UserUsecaseSuite) TestPatch() {
+
+	s.Run("should patch user at the given version", func() {
+		set := map[string]interface{}{"name": "test"}
+		unset := []string{"birth_date"}
+
+		s.userUseCase.Patch(s.ctx, "mock-id", 3, set, unset)
+
+		s.userRepo.AssertCalled(s.T(), "Patch", s.ctx, "mock-id", int64(3), set, unset)
+	})
+
+	s.Run("should return error when patch user failed", func() {
+		s.errUserRepoPatch = errors.New("patch failed")
+
+		res, err := s.userUseCase.Patch(s.ctx, "mock-id", 0, nil, nil)
+
+		s.Equal(entity.User{}, res)
+		s.EqualError(err, "patch failed")
+	})
+
+	s.Run("should audit the changed fields and return the patched user", func() {
+		s.resUserRepoGetByID = entity.User{ID: "mock-id", Name: "before", Version: 1}
+		s.resUserRepoPatch = entity.User{ID: "mock-id", Name: "after", Version: 2}
+		s.errUserRepoPatch = nil
+		s.auditRecords = nil
+
+		res, err := s.userUseCase.Patch(s.ctx, "mock-id", 1, map[string]interface{}{"name": "after"}, nil)
+
+		s.Nil(err)
+		s.Equal(s.resUserRepoPatch, res)
+		s.Require().Len(s.auditRecords, 1)
+		s.Equal(entity.AuditActionUpdate, s.auditRecords[0].Action)
+		s.Equal([]entity.FieldChange{
+			{Field: "name", Before: "before", After: "after"},
+			{Field: "version", Before: int64(1), After: int64(2)},
+		}, s.auditRecords[0].Changes)
+	})
+}
+
 func (s *UserUsecaseSuite) TestDelete() {
 
 	s.Run("should delete user", func() {
 		id := "mock-id"
 
-		s.userUseCase.Delete(id)
+		s.userUseCase.Delete(s.ctx, id)
 
-		s.userRepo.AssertCalled(s.T(), "Delete", id)
+		s.userRepo.AssertCalled(s.T(), "Delete", s.ctx, id)
 	})
 
 	s.Run("should return error when delete user failed", func() {
 		id := "mock-id"
 		s.errUserRepoDelete = errors.New("delete failed")
 
-		err := s.userUseCase.Delete(id)
+		err := s.userUseCase.Delete(s.ctx, id)
 
 		s.EqualError(err, "delete failed")
 	})
@@ -274,8 +603,115 @@ func (s *UserUsecaseSuite) TestDelete() {
 		id := "mock-id"
 		s.errUserRepoDelete = nil
 
-		err := s.userUseCase.Delete(id)
+		err := s.userUseCase.Delete(s.ctx, id)
+
+		s.Nil(err)
+	})
+}
+
+func (s *UserUsecaseSuite) TestRestore() {
+
+	s.Run("should return error when restore failed", func() {
+		s.errUserRepoRestore = errors.New("restore failed")
+
+		_, err := s.userUseCase.Restore(s.ctx, "mock-id")
+
+		s.EqualError(err, "restore failed")
+	})
+
+	s.Run("should return restored user when restore success", func() {
+		s.errUserRepoRestore = nil
+		s.resUserRepoGetByID = entity.User{ID: "mock-id", Name: "test"}
+
+		res, err := s.userUseCase.Restore(s.ctx, "mock-id")
+
+		s.userRepo.AssertCalled(s.T(), "Restore", s.ctx, "mock-id")
+		s.Equal(s.resUserRepoGetByID, res)
+		s.Nil(err)
+	})
+
+	s.Run("should return error when the user is not deleted", func() {
+		s.errUserRepoGetDeletedByID = apperror.NewError("Deleted user not found", "Deleted user not found", apperror.NotFound)
+
+		_, err := s.userUseCase.Restore(s.ctx, "mock-id")
+
+		s.EqualError(err, "Deleted user not found")
+	})
+
+	s.Run("should record the deleted user as before", func() {
+		deletedAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		s.errUserRepoGetDeletedByID = nil
+		s.resUserRepoGetDeletedByID = entity.User{ID: "mock-id", Name: "test", DeletedAt: &deletedAt}
+		s.resUserRepoGetByID = entity.User{ID: "mock-id", Name: "test"}
+		s.auditRecords = nil
+
+		_, err := s.userUseCase.Restore(s.ctx, "mock-id")
+
+		s.Nil(err)
+		s.Require().Len(s.auditRecords, 1)
+		s.Equal(entity.AuditActionRestore, s.auditRecords[0].Action)
+		s.Equal([]entity.FieldChange{{Field: "deleted_at", Before: deletedAt, After: nil}}, s.auditRecords[0].Changes)
+	})
+}
+
+func (s *UserUsecaseSuite) TestPurgeDeleted() {
+
+	s.Run("should return deleted count when purge success", func() {
+		before := time.Now()
+		s.resUserRepoPurgeDeleted = 2
+
+		res, err := s.userUseCase.PurgeDeleted(s.ctx, before)
+
+		s.userRepo.AssertCalled(s.T(), "PurgeDeleted", s.ctx, before)
+		s.Equal(int64(2), res)
+		s.Nil(err)
+	})
+}
+
+func (s *UserUsecaseSuite) TestAudit() {
+
+	s.Run("should record the actor, request and fields of a created user", func() {
+		user := entity.User{Name: "test", Password: "password"}
+		s.resUserRepoCreate = "mock-id"
+
+		_, err := s.userUseCase.Create(s.ctx, &user)
+
+		s.Nil(err)
+		s.Len(s.auditRecords, 1)
+		record := s.auditRecords[0]
+		s.Equal(entity.AuditActionCreate, record.Action)
+		s.Equal("mock-id", record.EntityID)
+		s.Equal("mock-actor-id", record.Actor.UserID)
+		s.Equal("mock-request-id", record.RequestID)
+		s.Contains(record.Changes, entity.FieldChange{Field: "name", Before: nil, After: "test"})
+		s.Contains(record.Changes, entity.FieldChange{Field: "password", Before: nil, After: entity.RedactedValue})
+	})
+
+	s.Run("should store a hash instead of the password", func() {
+		user := entity.User{Name: "test", Password: "A1b2C3d$"}
+
+		_, err := s.userUseCase.Create(s.ctx, &user)
+
+		s.Nil(err)
+		s.True(password.IsHashed(user.Password))
+	})
+
+	s.Run("should fail the write when the audit record cannot be written", func() {
+		s.errAuditRepoAdd = errors.New("audit failed")
+
+		err := s.userUseCase.Delete(s.ctx, "mock-id")
+
+		s.EqualError(err, "audit failed")
+	})
+
+	s.Run("should return the audit records of the user", func() {
+		s.resAuditRepoList = []entity.AuditRecord{{EntityID: "mock-id", Action: entity.AuditActionUpdate}}
+		pagination := adapter.Pagination{Limit: 10}
+
+		res, _, err := s.userUseCase.GetAudit(s.ctx, "mock-id", pagination)
 
+		s.auditRepo.AssertCalled(s.T(), "List", s.ctx, entity.AuditEntityUser, "mock-id", pagination)
+		s.Equal(s.resAuditRepoList, res)
 		s.Nil(err)
 	})
 }
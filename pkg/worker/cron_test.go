@@ -0,0 +1,90 @@
+package worker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/worker"
+)
+
+type CronSuite struct {
+	suite.Suite
+}
+
+func TestCronSuite(t *testing.T) {
+	suite.Run(t, new(CronSuite))
+}
+
+func (s *CronSuite) next(expr string, from string) string {
+	schedule, err := worker.ParseCron(expr)
+	s.Require().NoError(err)
+
+	t, err := time.Parse(time.RFC3339, from)
+	s.Require().NoError(err)
+
+	return schedule.Next(t).Format(time.RFC3339)
+}
+
+func (s *CronSuite) TestParseCron() {
+
+	s.Run("should reject expressions with the wrong number of fields", func() {
+		_, err := worker.ParseCron("* * * *")
+		s.Error(err)
+	})
+
+	s.Run("should reject out of range values", func() {
+		_, err := worker.ParseCron("60 * * * *")
+		s.Error(err)
+	})
+
+	s.Run("should reject inverted ranges", func() {
+		_, err := worker.ParseCron("* 10-2 * * *")
+		s.Error(err)
+	})
+}
+
+func (s *CronSuite) TestNext() {
+
+	s.Run("should move to the next minute for every-minute expressions", func() {
+		s.Equal("2026-10-18T10:31:00Z", s.next("* * * * *", "2026-10-18T10:30:15Z"))
+	})
+
+	s.Run("should honor steps", func() {
+		s.Equal("2026-10-18T10:45:00Z", s.next("*/15 * * * *", "2026-10-18T10:30:00Z"))
+	})
+
+	s.Run("should roll over to the next day", func() {
+		s.Equal("2026-10-19T02:00:00Z", s.next("0 2 * * *", "2026-10-18T03:00:00Z"))
+	})
+
+	s.Run("should support descriptors and names", func() {
+		s.Equal("2026-11-01T00:00:00Z", s.next("@monthly", "2026-10-18T03:00:00Z"))
+		s.Equal("2026-10-19T09:00:00Z", s.next("0 9 * * MON-FRI", "2026-10-18T10:00:00Z"))
+	})
+
+	s.Run("should match either day of month or day of week when both are restricted", func() {
+		// 2026-10-18 is a Sunday; the 20th is a Tuesday.
+		s.Equal("2026-10-20T00:00:00Z", s.next("0 0 20 * 2", "2026-10-18T00:00:00Z"))
+		s.Equal("2026-10-20T00:00:00Z", s.next("0 0 25 * TUE", "2026-10-18T00:00:00Z"))
+	})
+
+	s.Run("should treat 7 as Sunday", func() {
+		s.Equal("2026-10-25T00:00:00Z", s.next("0 0 * * 7", "2026-10-18T00:00:00Z"))
+	})
+
+	s.Run("should skip months without the requested day", func() {
+		s.Equal("2027-02-28T00:00:00Z", s.next("0 0 28 2 *", "2026-10-18T00:00:00Z"))
+		s.Equal("2026-10-31T00:00:00Z", s.next("0 0 31 * *", "2026-10-18T00:00:00Z"))
+		s.Equal("2026-12-31T00:00:00Z", s.next("0 0 31 * *", "2026-10-31T00:00:00Z"))
+	})
+}
+
+func (s *CronSuite) TestEvery() {
+
+	s.Run("should align intervals", func() {
+		t := time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC)
+
+		s.Equal(time.Date(2026, 10, 18, 10, 10, 0, 0, time.UTC), worker.Every(5*time.Minute).Next(t))
+	})
+}
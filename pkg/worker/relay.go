@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+)
+
+type IRelay interface {
+	// Run publishes outbox events as jobs until ctx is done. Every event is published at least once;
+	// an event becomes a job of the same type whose ID is the event ID.
+	Run(ctx context.Context) error
+}
+
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int64
+}
+
+const (
+	defaultRelayPollInterval = time.Second
+	defaultRelayBatchSize    = 100
+)
+
+type relay struct {
+	outbox       adapter.IOutbox
+	queue        adapter.IQueue
+	pollInterval time.Duration
+	batchSize    int64
+	logger       log.ILogger
+}
+
+func NewRelay(relayConfig RelayConfig, outbox adapter.IOutbox, queue adapter.IQueue, logger log.ILogger) IRelay {
+	if relayConfig.PollInterval <= 0 {
+		relayConfig.PollInterval = defaultRelayPollInterval
+	}
+	if relayConfig.BatchSize <= 0 {
+		relayConfig.BatchSize = defaultRelayBatchSize
+	}
+
+	return &relay{
+		outbox:       outbox,
+		queue:        queue,
+		pollInterval: relayConfig.PollInterval,
+		batchSize:    relayConfig.BatchSize,
+		logger:       logger,
+	}
+}
+
+func (r *relay) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+
+		published, err := r.publish(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error(ctx, "failed to relay outbox events", log.Error(err))
+		}
+
+		// A full batch means more events are probably waiting.
+		if err == nil && int64(published) == r.batchSize {
+			timer.Reset(0)
+		} else {
+			timer.Reset(r.pollInterval)
+		}
+	}
+}
+
+// publish enqueues one batch of pending events and returns how many were published.
+func (r *relay) publish(ctx context.Context) (int, error) {
+	events, err := r.outbox.Pending(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, event := range events {
+		_, err := r.queue.Enqueue(ctx, &entity.Job{
+			ID:      event.ID,
+			Type:    event.Type,
+			Payload: event.Payload,
+		})
+		// The job already exists when a previous attempt enqueued it but failed to mark the event.
+		if err != nil && !errors.Is(err, adapter.ErrJobExists) {
+			return i, err
+		}
+
+		if err := r.outbox.MarkPublished(ctx, event.ID); err != nil {
+			return i, err
+		}
+
+		r.logger.Debug(ctx, "outbox event published",
+			log.String("eventID", event.ID.Hex()),
+			log.String("eventType", event.Type),
+		)
+	}
+
+	return len(events), nil
+}
@@ -0,0 +1,122 @@
+package worker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/worker"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryOutbox is an in-memory adapter.IOutbox.
+type memoryOutbox struct {
+	mu     sync.Mutex
+	events []entity.OutboxEvent
+}
+
+func (o *memoryOutbox) Add(ctx context.Context, event *entity.OutboxEvent) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	event.ID = primitive.NewObjectID()
+	o.events = append(o.events, *event)
+	return nil
+}
+
+func (o *memoryOutbox) Pending(ctx context.Context, limit int64) ([]entity.OutboxEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	events := []entity.OutboxEvent{}
+	for _, event := range o.events {
+		if event.PublishedAt == nil && int64(len(events)) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (o *memoryOutbox) MarkPublished(ctx context.Context, id primitive.ObjectID) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	for i := range o.events {
+		if o.events[i].ID == id {
+			o.events[i].PublishedAt = &now
+		}
+	}
+	return nil
+}
+
+func (o *memoryOutbox) unpublished() int {
+	events, _ := o.Pending(context.Background(), int64(len(o.events)+1))
+	return len(events)
+}
+
+type RelaySuite struct {
+	suite.Suite
+	logger log.ILogger
+}
+
+func (s *RelaySuite) SetupSuite() {
+	var err error
+	s.logger, err = log.NewLoggerZap(&log.ZapConfig{})
+	s.NoError(err)
+}
+
+func TestRelaySuite(t *testing.T) {
+	suite.Run(t, new(RelaySuite))
+}
+
+func (s *RelaySuite) run(outbox *memoryOutbox, queue *memoryQueue) {
+	relay := worker.NewRelay(worker.RelayConfig{PollInterval: time.Millisecond, BatchSize: 2}, outbox, queue, s.logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(ctx)
+	}()
+
+	s.Eventually(func() bool { return outbox.unpublished() == 0 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+}
+
+func (s *RelaySuite) TestRun() {
+
+	s.Run("should enqueue every event as a job with the event ID", func() {
+		outbox := &memoryOutbox{}
+		queue := &memoryQueue{}
+		for i := 0; i < 5; i++ {
+			s.NoError(outbox.Add(context.Background(), &entity.OutboxEvent{Type: entity.EventUserCreated}))
+		}
+
+		s.run(outbox, queue)
+
+		s.Len(queue.pending, 5)
+		for i, job := range queue.pending {
+			s.Equal(outbox.events[i].ID, job.ID)
+			s.Equal(entity.EventUserCreated, job.Type)
+		}
+	})
+
+	s.Run("should mark an event published when its job was already enqueued", func() {
+		outbox := &memoryOutbox{}
+		queue := &memoryQueue{}
+		event := &entity.OutboxEvent{Type: entity.EventUserUpdated}
+		s.NoError(outbox.Add(context.Background(), event))
+		_, err := queue.Enqueue(context.Background(), &entity.Job{ID: event.ID, Type: event.Type})
+		s.NoError(err)
+
+		s.run(outbox, queue)
+
+		s.Len(queue.pending, 1)
+	})
+}
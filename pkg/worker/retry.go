@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/apperror"
+)
+
+// RetryPolicy decides whether and when a failed job runs again.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction of each delay, between 0 and 1, that is randomized.
+	Jitter float64
+	// NonRetryable lists error codes that send a job straight to the dead-letter collection.
+	NonRetryable []apperror.AppErrorCode
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Minute,
+		Multiplier:     2,
+		Jitter:         0.2,
+		NonRetryable:   []apperror.AppErrorCode{apperror.NotFound},
+	}
+}
+
+// Backoff returns how long to wait before the attempt that follows the given one.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay = delay*(1-jitter) + rand.Float64()*delay*jitter
+	}
+
+	return time.Duration(delay)
+}
+
+// IsRetryable reports whether err may succeed on another attempt.
+func (p RetryPolicy) IsRetryable(err error) bool {
+	var appErr apperror.AppError
+	if !errors.As(err, &appErr) {
+		return true
+	}
+
+	for _, code := range p.NonRetryable {
+		if appErr.Code == code {
+			return false
+		}
+	}
+	return true
+}
+
+// ShouldRetry reports whether a job that failed with err on the given attempt should run again.
+func (p RetryPolicy) ShouldRetry(attempt int, err error) bool {
+	return attempt < p.MaxAttempts && p.IsRetryable(err)
+}
@@ -0,0 +1,356 @@
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CatchUpPolicy decides what happens to runs that were missed while no scheduler was running.
+type CatchUpPolicy string
+
+const (
+	// CatchUpSkip drops missed runs.
+	CatchUpSkip CatchUpPolicy = "skip"
+	// CatchUpRunOnce fires a single run for any number of missed runs.
+	CatchUpRunOnce CatchUpPolicy = "run_once"
+	// CatchUpRunAll fires every missed run, up to SchedulerConfig.MaxCatchUpRuns in total.
+	CatchUpRunAll CatchUpPolicy = "run_all"
+)
+
+// ScheduledJob enqueues a job of JobType whenever its schedule comes due.
+// Exactly one of Cron and Every must be set.
+type ScheduledJob struct {
+	// Name identifies the schedule across replicas and restarts.
+	Name    string
+	Cron    string
+	Every   time.Duration
+	JobType string
+	Payload map[string]interface{}
+	// CatchUp defaults to CatchUpSkip.
+	CatchUp CatchUpPolicy
+}
+
+type IScheduler interface {
+	// Add validates and registers a scheduled job. It must be called before Run.
+	Add(job ScheduledJob) error
+	// Run enqueues due jobs until ctx is done. Only the replica holding the scheduler lock enqueues.
+	Run(ctx context.Context) error
+}
+
+type SchedulerConfig struct {
+	// ID identifies this replica in the scheduler lock. Defaults to hostname-pid.
+	ID             string
+	CollectionName string
+	TickInterval   time.Duration
+	LockTTL        time.Duration
+	// MisfireThreshold is how late a run may be and still count as on time rather than missed.
+	MisfireThreshold time.Duration
+	MaxCatchUpRuns   int
+}
+
+const (
+	schedulerLockName = "scheduler"
+
+	defaultScheduleCollectionName = "job_schedules"
+	defaultTickInterval           = 5 * time.Second
+	defaultLockTTL                = 30 * time.Second
+	defaultMisfireThreshold       = time.Minute
+	defaultMaxCatchUpRuns         = 100
+)
+
+type scheduledJob struct {
+	ScheduledJob
+	schedule Schedule
+}
+
+type scheduler struct {
+	id               string
+	mongoDBAdapter   adapter.IMongoDBAdapter
+	collection       adapter.IMongoCollection
+	lock             adapter.ILock
+	queue            adapter.IQueue
+	jobs             []scheduledJob
+	mu               sync.RWMutex
+	tickInterval     time.Duration
+	lockTTL          time.Duration
+	misfireThreshold time.Duration
+	maxCatchUpRuns   int
+	logger           log.ILogger
+}
+
+func NewScheduler(
+	schedulerConfig SchedulerConfig,
+	mongoDBAdapter adapter.IMongoDBAdapter,
+	database *mongo.Database,
+	lock adapter.ILock,
+	queue adapter.IQueue,
+	logger log.ILogger,
+) IScheduler {
+	if schedulerConfig.ID == "" {
+		hostname, _ := os.Hostname()
+		schedulerConfig.ID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if schedulerConfig.CollectionName == "" {
+		schedulerConfig.CollectionName = defaultScheduleCollectionName
+	}
+	if schedulerConfig.TickInterval <= 0 {
+		schedulerConfig.TickInterval = defaultTickInterval
+	}
+	if schedulerConfig.LockTTL <= 0 {
+		schedulerConfig.LockTTL = defaultLockTTL
+	}
+	if schedulerConfig.MisfireThreshold <= 0 {
+		schedulerConfig.MisfireThreshold = defaultMisfireThreshold
+	}
+	if schedulerConfig.MaxCatchUpRuns <= 0 {
+		schedulerConfig.MaxCatchUpRuns = defaultMaxCatchUpRuns
+	}
+
+	return &scheduler{
+		id:               schedulerConfig.ID,
+		mongoDBAdapter:   mongoDBAdapter,
+		collection:       database.Collection(schedulerConfig.CollectionName),
+		lock:             lock,
+		queue:            queue,
+		tickInterval:     schedulerConfig.TickInterval,
+		lockTTL:          schedulerConfig.LockTTL,
+		misfireThreshold: schedulerConfig.MisfireThreshold,
+		maxCatchUpRuns:   schedulerConfig.MaxCatchUpRuns,
+		logger:           logger,
+	}
+}
+
+func (s *scheduler) Add(job ScheduledJob) error {
+	if job.Name == "" || job.JobType == "" {
+		return errors.New("scheduled job needs a name and a job type")
+	}
+
+	var schedule Schedule
+	switch {
+	case job.Cron != "" && job.Every > 0:
+		return fmt.Errorf("scheduled job %q must set either Cron or Every, not both", job.Name)
+	case job.Cron != "":
+		cron, err := ParseCron(job.Cron)
+		if err != nil {
+			return fmt.Errorf("scheduled job %q: %w", job.Name, err)
+		}
+		schedule = cron
+	case job.Every > 0:
+		schedule = Every(job.Every)
+	default:
+		return fmt.Errorf("scheduled job %q must set Cron or Every", job.Name)
+	}
+
+	switch job.CatchUp {
+	case "":
+		job.CatchUp = CatchUpSkip
+	case CatchUpSkip, CatchUpRunOnce, CatchUpRunAll:
+	default:
+		return fmt.Errorf("scheduled job %q has unknown catch-up policy %q", job.Name, job.CatchUp)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.jobs {
+		if existing.Name == job.Name {
+			return fmt.Errorf("scheduled job %q is already registered", job.Name)
+		}
+	}
+	s.jobs = append(s.jobs, scheduledJob{job, schedule})
+
+	return nil
+}
+
+func (s *scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.tickInterval)
+	defer ticker.Stop()
+
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.lock.Release(releaseCtx, schedulerLockName, s.id); err != nil {
+			s.logger.Warn(releaseCtx, "failed to release scheduler lock", log.Error(err))
+		}
+	}()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *scheduler) tick(ctx context.Context) {
+	isLeader, err := s.lock.Acquire(ctx, schedulerLockName, s.id, s.lockTTL)
+	if err != nil {
+		s.logger.Error(ctx, "failed to acquire scheduler lock", log.Error(err))
+		return
+	}
+	if !isLeader {
+		return
+	}
+
+	s.mu.RLock()
+	jobs := s.jobs
+	s.mu.RUnlock()
+
+	for _, job := range jobs {
+		if err := s.fire(ctx, job, time.Now()); err != nil {
+			s.logger.Error(ctx, "failed to fire scheduled job", log.String("schedule", job.Name), log.Error(err))
+		}
+	}
+}
+
+// fire enqueues the runs of job that are due at now, according to its catch-up policy.
+func (s *scheduler) fire(ctx context.Context, job scheduledJob, now time.Time) error {
+	var state entity.JobSchedule
+	err := s.mongoDBAdapter.FindOne(ctx, s.collection, &state, bson.D{{Key: "_id", Value: job.Name}})
+	if errors.Is(err, adapter.ErrNoDocuments) {
+		// First sighting of this schedule: start counting from now instead of firing immediately.
+		_, err = s.mongoDBAdapter.UpdateOne(ctx, s.collection,
+			bson.D{{Key: "_id", Value: job.Name}},
+			bson.D{{Key: "$setOnInsert", Value: bson.D{{Key: "next_run_at", Value: job.schedule.Next(now)}}}},
+			options.Update().SetUpsert(true),
+		)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if state.NextRunAt.After(now) {
+		return nil
+	}
+
+	// Every run is enqueued under an ID derived from its schedule and time before next_run_at is advanced, so a
+	// failed enqueue is retried on the next tick and a run enqueued twice, by a retried tick or by a replica that
+	// lost the lock mid-tick, is rejected as a duplicate.
+	for _, runAt := range s.dueRuns(job, state.NextRunAt, now) {
+		_, err := s.queue.Enqueue(ctx, &entity.Job{
+			ID:      runID(job.Name, runAt),
+			Type:    job.JobType,
+			Payload: job.Payload,
+			RunAt:   runAt,
+		})
+		if errors.Is(err, adapter.ErrJobExists) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("enqueue run at %s: %w", runAt.Format(time.RFC3339), err)
+		}
+		s.logger.Info(ctx, "scheduled job enqueued",
+			log.String("schedule", job.Name),
+			log.String("jobType", job.JobType),
+			log.String("scheduledAt", runAt.Format(time.RFC3339)),
+		)
+	}
+
+	// Only the replica whose update matches the old value advances the schedule.
+	_, err = s.mongoDBAdapter.UpdateOne(ctx, s.collection,
+		bson.D{
+			{Key: "_id", Value: job.Name},
+			{Key: "next_run_at", Value: state.NextRunAt},
+		},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "next_run_at", Value: job.schedule.Next(now)},
+			{Key: "last_run_at", Value: now},
+		}}},
+	)
+	return err
+}
+
+// runID is the job ID of the run of a schedule at runAt: its timestamp is runAt and the rest is a hash of both, so
+// enqueueing the same run again always yields the same ID.
+func runID(name string, runAt time.Time) primitive.ObjectID {
+	sum := sha256.Sum256([]byte(name + "@" + runAt.UTC().Format(time.RFC3339Nano)))
+
+	var id primitive.ObjectID
+	binary.BigEndian.PutUint32(id[:4], uint32(runAt.Unix()))
+	copy(id[4:], sum[:])
+	return id
+}
+
+// dueRuns lists the activations of job from next up to now that its catch-up policy allows.
+func (s *scheduler) dueRuns(job scheduledJob, next time.Time, now time.Time) []time.Time {
+	if job.CatchUp == CatchUpRunAll {
+		return s.catchUpRuns(job, next, now)
+	}
+
+	// Runs before cutoff are missed. Skip and run once need at most the last of them, so the scan starts at cutoff
+	// rather than walking every activation missed while no worker was up.
+	cutoff := now.Add(-s.misfireThreshold)
+	var runs []time.Time
+	if next.Before(cutoff) {
+		if job.CatchUp == CatchUpRunOnce {
+			runs = append(runs, lastBefore(job.schedule, next, cutoff))
+		}
+		next = job.schedule.Next(cutoff.Add(-time.Nanosecond))
+	}
+	for t := next; !t.IsZero() && !t.After(now); t = job.schedule.Next(t) {
+		runs = append(runs, t)
+	}
+	return runs
+}
+
+// catchUpRuns lists every activation from next up to now, keeping only the most recent MaxCatchUpRuns.
+func (s *scheduler) catchUpRuns(job scheduledJob, next time.Time, now time.Time) []time.Time {
+	var (
+		missed []time.Time
+		onTime []time.Time
+	)
+	for t := next; !t.IsZero() && !t.After(now); t = job.schedule.Next(t) {
+		if now.Sub(t) <= s.misfireThreshold {
+			onTime = append(onTime, t)
+			continue
+		}
+		// Only the most recent missed runs matter once the cap is reached.
+		if len(missed) == s.maxCatchUpRuns {
+			missed = missed[1:]
+		}
+		missed = append(missed, t)
+	}
+
+	runs := append(missed, onTime...)
+	if len(runs) > s.maxCatchUpRuns {
+		runs = runs[len(runs)-s.maxCatchUpRuns:]
+	}
+	return runs
+}
+
+// lastBefore returns the last activation of schedule before end, given first, an activation before end. It looks
+// back from end over a window that doubles until it holds an activation, so a long gap costs a few calls to Next.
+func lastBefore(schedule Schedule, first time.Time, end time.Time) time.Time {
+	last := first
+	for window := time.Second; ; window *= 2 {
+		from := end.Add(-window)
+		if !from.After(first) {
+			break
+		}
+		if t := schedule.Next(from.Add(-time.Nanosecond)); !t.IsZero() && t.Before(end) {
+			last = t
+			break
+		}
+	}
+	for t := schedule.Next(last); !t.IsZero() && t.Before(end); t = schedule.Next(t) {
+		last = t
+	}
+	return last
+}
@@ -0,0 +1,310 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// scheduleAdapter keeps the state of a single schedule in memory, as the schedule collection would.
+type scheduleAdapter struct {
+	adapter.IMongoDBAdapter
+	state *entity.JobSchedule
+	// raced advances the schedule after it is read, as another replica firing it first would.
+	raced bool
+}
+
+func (a *scheduleAdapter) FindOne(ctx context.Context, collection adapter.IMongoCollection, result interface{}, filter interface{}, opts ...*options.FindOneOptions) error {
+	if a.state == nil {
+		return adapter.ErrNoDocuments
+	}
+	*result.(*entity.JobSchedule) = *a.state
+	if a.raced {
+		a.state.NextRunAt = a.state.NextRunAt.Add(time.Hour)
+	}
+	return nil
+}
+
+func (a *scheduleAdapter) UpdateOne(ctx context.Context, collection adapter.IMongoCollection, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (bool, error) {
+	var f, u struct {
+		NextRunAt   *time.Time `bson:"next_run_at"`
+		Set         bson.M     `bson:"$set"`
+		SetOnInsert bson.M     `bson:"$setOnInsert"`
+	}
+	decode(filter, &f)
+	decode(update, &u)
+
+	if a.state == nil {
+		a.state = &entity.JobSchedule{NextRunAt: u.SetOnInsert["next_run_at"].(primitive.DateTime).Time().UTC()}
+		return true, nil
+	}
+	if f.NextRunAt != nil && !f.NextRunAt.Equal(a.state.NextRunAt) {
+		return false, nil
+	}
+	lastRunAt := u.Set["last_run_at"].(primitive.DateTime).Time().UTC()
+	a.state.NextRunAt = u.Set["next_run_at"].(primitive.DateTime).Time().UTC()
+	a.state.LastRunAt = &lastRunAt
+	return true, nil
+}
+
+func decode(document interface{}, v interface{}) {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		panic(err)
+	}
+	if err := bson.Unmarshal(raw, v); err != nil {
+		panic(err)
+	}
+}
+
+// runQueue records the runs enqueued by the scheduler and rejects a job ID it already holds, as the queue does.
+type runQueue struct {
+	adapter.IQueue
+	runs []time.Time
+	ids  map[primitive.ObjectID]bool
+	// fail makes Enqueue fail for the run at that time.
+	fail time.Time
+}
+
+func (q *runQueue) Enqueue(ctx context.Context, job *entity.Job) (*primitive.ObjectID, error) {
+	if job.RunAt.Equal(q.fail) {
+		return nil, errors.New("queue is unavailable")
+	}
+	if q.ids[job.ID] {
+		return nil, adapter.ErrJobExists
+	}
+	if q.ids == nil {
+		q.ids = map[primitive.ObjectID]bool{}
+	}
+	q.ids[job.ID] = true
+	q.runs = append(q.runs, job.RunAt)
+	return &job.ID, nil
+}
+
+type SchedulerSuite struct {
+	suite.Suite
+	logger log.ILogger
+}
+
+func (s *SchedulerSuite) SetupSuite() {
+	var err error
+	s.logger, err = log.NewLoggerZap(&log.ZapConfig{})
+	s.Require().NoError(err)
+}
+
+func TestSchedulerSuite(t *testing.T) {
+	suite.Run(t, new(SchedulerSuite))
+}
+
+// at parses a time of 1 January 2024, UTC.
+func (s *SchedulerSuite) at(clock string) time.Time {
+	t, err := time.Parse(time.RFC3339, "2024-01-01T"+clock+"Z")
+	s.Require().NoError(err)
+	return t
+}
+
+func (s *SchedulerSuite) ats(clocks ...string) []time.Time {
+	times := []time.Time{}
+	for _, clock := range clocks {
+		times = append(times, s.at(clock))
+	}
+	return times
+}
+
+func (s *SchedulerSuite) hourly(policy CatchUpPolicy) scheduledJob {
+	return scheduledJob{
+		ScheduledJob: ScheduledJob{Name: "hourly", Every: time.Hour, JobType: "report", CatchUp: policy},
+		schedule:     Every(time.Hour),
+	}
+}
+
+func (s *SchedulerSuite) TestDueRuns() {
+	tests := []struct {
+		name           string
+		policy         CatchUpPolicy
+		maxCatchUpRuns int
+		next           string
+		now            string
+		want           []string
+	}{
+		{"skip should fire a run on time", CatchUpSkip, 100, "10:00:00", "10:00:30", []string{"10:00:00"}},
+		{"run once should fire a run on time", CatchUpRunOnce, 100, "10:00:00", "10:00:30", []string{"10:00:00"}},
+		{"run all should fire a run on time", CatchUpRunAll, 100, "10:00:00", "10:00:30", []string{"10:00:00"}},
+
+		{"skip should drop missed runs", CatchUpSkip, 100, "07:00:00", "10:00:30", []string{"10:00:00"}},
+		{"run once should fire the last missed run", CatchUpRunOnce, 100, "07:00:00", "10:00:30", []string{"09:00:00", "10:00:00"}},
+		{"run all should fire every missed run", CatchUpRunAll, 100, "07:00:00", "10:00:30", []string{"07:00:00", "08:00:00", "09:00:00", "10:00:00"}},
+
+		{"skip should fire nothing when every run was missed", CatchUpSkip, 100, "08:00:00", "10:30:00", []string{}},
+		{"run once should fire once when every run was missed", CatchUpRunOnce, 100, "08:00:00", "10:30:00", []string{"10:00:00"}},
+		{"run all should fire every run when every run was missed", CatchUpRunAll, 100, "08:00:00", "10:30:00", []string{"08:00:00", "09:00:00", "10:00:00"}},
+
+		{"run all should fire only the most recent runs past the cap", CatchUpRunAll, 2, "05:00:00", "10:00:30", []string{"09:00:00", "10:00:00"}},
+		{"run once should ignore the cap", CatchUpRunOnce, 2, "05:00:00", "10:30:00", []string{"10:00:00"}},
+	}
+
+	for _, test := range tests {
+		s.Run(test.name, func() {
+			scheduler := &scheduler{misfireThreshold: time.Minute, maxCatchUpRuns: test.maxCatchUpRuns}
+
+			runs := scheduler.dueRuns(s.hourly(test.policy), s.at(test.next), s.at(test.now))
+
+			s.Equal(s.ats(test.want...), append([]time.Time{}, runs...))
+		})
+	}
+}
+
+func (s *SchedulerSuite) TestDueRunsAfterLongOutage() {
+	// A week of a schedule every second: walking every missed run would call Next 600k times.
+	calls := 0
+	every := countingSchedule{Every(time.Second), &calls}
+	next, now := s.at("10:00:00").AddDate(0, 0, -7), s.at("10:00:30")
+
+	for _, test := range []struct {
+		policy CatchUpPolicy
+		first  time.Time
+	}{
+		{CatchUpSkip, s.at("09:59:30")},
+		{CatchUpRunOnce, s.at("09:59:29")},
+	} {
+		s.Run(string(test.policy)+" should only scan the on-time window", func() {
+			calls = 0
+			scheduler := &scheduler{misfireThreshold: time.Minute, maxCatchUpRuns: 100}
+			job := scheduledJob{ScheduledJob: ScheduledJob{Name: "tick", CatchUp: test.policy}, schedule: every}
+
+			runs := scheduler.dueRuns(job, next, now)
+
+			s.Equal(test.first, runs[0])
+			s.Equal(now, runs[len(runs)-1])
+			s.Less(calls, 200)
+		})
+	}
+
+	s.Run("run once should find the last missed run of a sparse cron", func() {
+		cron, err := ParseCron("0 0 1 * *")
+		s.Require().NoError(err)
+		scheduler := &scheduler{misfireThreshold: time.Minute, maxCatchUpRuns: 100}
+		job := scheduledJob{ScheduledJob: ScheduledJob{Name: "monthly", CatchUp: CatchUpRunOnce}, schedule: cron}
+
+		runs := scheduler.dueRuns(job, s.at("00:00:00").AddDate(-1, 0, 0), s.at("00:00:00").AddDate(0, 2, 14))
+
+		s.Equal([]time.Time{s.at("00:00:00").AddDate(0, 2, 0)}, runs)
+	})
+}
+
+// countingSchedule counts the calls to Next of the schedule it wraps.
+type countingSchedule struct {
+	Schedule
+	calls *int
+}
+
+func (s countingSchedule) Next(t time.Time) time.Time {
+	*s.calls++
+	return s.Schedule.Next(t)
+}
+
+func (s *SchedulerSuite) TestFire() {
+	newScheduler := func(mongoDBAdapter adapter.IMongoDBAdapter, queue adapter.IQueue) *scheduler {
+		return &scheduler{
+			mongoDBAdapter:   mongoDBAdapter,
+			queue:            queue,
+			misfireThreshold: time.Minute,
+			maxCatchUpRuns:   100,
+			logger:           s.logger,
+		}
+	}
+
+	s.Run("should start counting from now the first time it sees a schedule", func() {
+		mongoDBAdapter, queue := &scheduleAdapter{}, &runQueue{}
+
+		err := newScheduler(mongoDBAdapter, queue).fire(context.Background(), s.hourly(CatchUpRunAll), s.at("10:20:00"))
+
+		s.NoError(err)
+		s.Empty(queue.runs)
+		s.Equal(s.at("11:00:00"), mongoDBAdapter.state.NextRunAt)
+	})
+
+	s.Run("should not fire a schedule that is not due", func() {
+		mongoDBAdapter := &scheduleAdapter{state: &entity.JobSchedule{Name: "hourly", NextRunAt: s.at("11:00:00")}}
+		queue := &runQueue{}
+
+		err := newScheduler(mongoDBAdapter, queue).fire(context.Background(), s.hourly(CatchUpRunAll), s.at("10:59:59"))
+
+		s.NoError(err)
+		s.Empty(queue.runs)
+		s.Equal(s.at("11:00:00"), mongoDBAdapter.state.NextRunAt)
+	})
+
+	tests := []struct {
+		policy CatchUpPolicy
+		want   []string
+	}{
+		{CatchUpSkip, []string{"10:00:00"}},
+		{CatchUpRunOnce, []string{"09:00:00", "10:00:00"}},
+		{CatchUpRunAll, []string{"08:00:00", "09:00:00", "10:00:00"}},
+	}
+	for _, test := range tests {
+		s.Run("should enqueue the due runs and advance the schedule with "+string(test.policy), func() {
+			mongoDBAdapter := &scheduleAdapter{state: &entity.JobSchedule{Name: "hourly", NextRunAt: s.at("08:00:00")}}
+			queue := &runQueue{}
+
+			err := newScheduler(mongoDBAdapter, queue).fire(context.Background(), s.hourly(test.policy), s.at("10:00:30"))
+
+			s.NoError(err)
+			s.Equal(s.ats(test.want...), queue.runs)
+			s.Equal(s.at("11:00:00"), mongoDBAdapter.state.NextRunAt)
+			s.Equal(s.at("10:00:30"), *mongoDBAdapter.state.LastRunAt)
+		})
+	}
+
+	s.Run("should not enqueue runs another replica fired first", func() {
+		queue := &runQueue{}
+		first := &scheduleAdapter{state: &entity.JobSchedule{Name: "hourly", NextRunAt: s.at("10:00:00")}}
+		s.Require().NoError(newScheduler(first, queue).fire(context.Background(), s.hourly(CatchUpRunAll), s.at("10:00:30")))
+
+		second := &scheduleAdapter{
+			state: &entity.JobSchedule{Name: "hourly", NextRunAt: s.at("10:00:00")},
+			raced: true,
+		}
+		err := newScheduler(second, queue).fire(context.Background(), s.hourly(CatchUpRunAll), s.at("10:00:40"))
+
+		s.NoError(err)
+		s.Equal(s.ats("10:00:00"), queue.runs)
+		s.Nil(second.state.LastRunAt)
+	})
+
+	s.Run("should keep the schedule and enqueue the remaining runs on the next tick when an enqueue fails", func() {
+		mongoDBAdapter := &scheduleAdapter{state: &entity.JobSchedule{Name: "hourly", NextRunAt: s.at("08:00:00")}}
+		queue := &runQueue{fail: s.at("09:00:00")}
+		scheduler := newScheduler(mongoDBAdapter, queue)
+
+		err := scheduler.fire(context.Background(), s.hourly(CatchUpRunAll), s.at("10:00:30"))
+
+		s.ErrorContains(err, "enqueue run at 2024-01-01T09:00:00Z")
+		s.Equal(s.ats("08:00:00"), queue.runs)
+		s.Equal(s.at("08:00:00"), mongoDBAdapter.state.NextRunAt)
+
+		queue.fail = time.Time{}
+		err = scheduler.fire(context.Background(), s.hourly(CatchUpRunAll), s.at("10:00:40"))
+
+		s.NoError(err)
+		s.Equal(s.ats("08:00:00", "09:00:00", "10:00:00"), queue.runs)
+		s.Equal(s.at("11:00:00"), mongoDBAdapter.state.NextRunAt)
+	})
+}
+
+func (s *SchedulerSuite) TestRunID() {
+	s.Equal(runID("hourly", s.at("10:00:00")), runID("hourly", s.at("10:00:00").In(time.FixedZone("ICT", 7*60*60))))
+	s.NotEqual(runID("hourly", s.at("10:00:00")), runID("hourly", s.at("11:00:00")))
+	s.NotEqual(runID("hourly", s.at("10:00:00")), runID("daily", s.at("10:00:00")))
+	s.Equal(s.at("10:00:00"), runID("hourly", s.at("10:00:00")).Timestamp().UTC())
+}
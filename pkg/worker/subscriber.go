@@ -0,0 +1,350 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ChangeOperation string
+
+const (
+	ChangeInsert  ChangeOperation = "insert"
+	ChangeUpdate  ChangeOperation = "update"
+	ChangeReplace ChangeOperation = "replace"
+	ChangeDelete  ChangeOperation = "delete"
+)
+
+// ChangeEvent is one change to a document of the watched collection.
+type ChangeEvent struct {
+	Operation  ChangeOperation
+	DocumentID bson.RawValue
+	// FullDocument is the current version of the document. It is empty for deletes, and for
+	// updates of a document that was deleted before the change was read.
+	FullDocument bson.Raw
+	// UpdatedFields and RemovedFields are only set for updates.
+	UpdatedFields bson.Raw
+	RemovedFields []string
+	ClusterTime   primitive.Timestamp
+}
+
+// ChangeHandlerFunc handles one change. Returning an error hands the change to it again, up to
+// SubscriberConfig.MaxAttempts times in all, after which the change is logged and skipped. Delivery is at least
+// once: a change handled just before a restart or a lost lock is delivered again, so handlers must be idempotent.
+type ChangeHandlerFunc func(ctx context.Context, event ChangeEvent) error
+
+// TypedChangeHandler decodes the full document of each change into T before calling fn.
+// document is nil when the change carries no full document.
+func TypedChangeHandler[T any](fn func(ctx context.Context, event ChangeEvent, document *T) error) ChangeHandlerFunc {
+	return func(ctx context.Context, event ChangeEvent) error {
+		if len(event.FullDocument) == 0 {
+			return fn(ctx, event, nil)
+		}
+
+		document := new(T)
+		if err := bson.Unmarshal(event.FullDocument, document); err != nil {
+			return err
+		}
+		return fn(ctx, event, document)
+	}
+}
+
+type ISubscriber interface {
+	// On registers a handler for an operation. It must be called before Run.
+	On(operation ChangeOperation, handler ChangeHandlerFunc)
+	// Run dispatches changes, at least once each, until ctx is done. Only the replica holding the subscriber lock
+	// watches.
+	Run(ctx context.Context) error
+}
+
+type SubscriberConfig struct {
+	// ID identifies this replica in the subscriber lock. Defaults to hostname-pid.
+	ID string
+	// Name identifies the subscription's resume token and lock across replicas and restarts.
+	Name                string
+	TokenCollectionName string
+	LockTTL             time.Duration
+	// RetryInterval is how long to wait before handing a failed change to its handler again, or before reopening
+	// the stream after an error.
+	RetryInterval time.Duration
+	// MaxAttempts is how many times a change is handed to its handler before it is skipped. Defaults to 5.
+	MaxAttempts int
+}
+
+const (
+	defaultTokenCollectionName = "change_stream_tokens"
+	defaultRetryInterval       = 5 * time.Second
+	defaultMaxAttempts         = 5
+
+	// Resuming fails with these codes when the token has fallen off the oplog.
+	changeStreamHistoryLost = 286
+	changeStreamFatalError  = 280
+)
+
+type changeStreamDocument struct {
+	OperationType ChangeOperation `bson:"operationType"`
+	DocumentKey   struct {
+		ID bson.RawValue `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument      bson.Raw `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.Raw `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+	ClusterTime primitive.Timestamp `bson:"clusterTime"`
+}
+
+type subscriber struct {
+	id              string
+	name            string
+	mongoDBAdapter  adapter.IMongoDBAdapter
+	collection      adapter.IMongoCollection
+	tokenCollection adapter.IMongoCollection
+	lock            adapter.ILock
+	handlers        map[ChangeOperation]ChangeHandlerFunc
+	mu              sync.RWMutex
+	lockTTL         time.Duration
+	retryInterval   time.Duration
+	maxAttempts     int
+	logger          log.ILogger
+}
+
+func NewSubscriber(
+	subscriberConfig SubscriberConfig,
+	mongoDBAdapter adapter.IMongoDBAdapter,
+	database *mongo.Database,
+	collection adapter.IMongoCollection,
+	lock adapter.ILock,
+	logger log.ILogger,
+) ISubscriber {
+	if subscriberConfig.ID == "" {
+		hostname, _ := os.Hostname()
+		subscriberConfig.ID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if subscriberConfig.Name == "" {
+		subscriberConfig.Name = collection.Name()
+	}
+	if subscriberConfig.TokenCollectionName == "" {
+		subscriberConfig.TokenCollectionName = defaultTokenCollectionName
+	}
+	if subscriberConfig.LockTTL <= 0 {
+		subscriberConfig.LockTTL = defaultLockTTL
+	}
+	if subscriberConfig.RetryInterval <= 0 {
+		subscriberConfig.RetryInterval = defaultRetryInterval
+	}
+	if subscriberConfig.MaxAttempts <= 0 {
+		subscriberConfig.MaxAttempts = defaultMaxAttempts
+	}
+
+	return &subscriber{
+		id:              subscriberConfig.ID,
+		name:            subscriberConfig.Name,
+		mongoDBAdapter:  mongoDBAdapter,
+		collection:      collection,
+		tokenCollection: database.Collection(subscriberConfig.TokenCollectionName),
+		lock:            lock,
+		handlers:        map[ChangeOperation]ChangeHandlerFunc{},
+		lockTTL:         subscriberConfig.LockTTL,
+		retryInterval:   subscriberConfig.RetryInterval,
+		maxAttempts:     subscriberConfig.MaxAttempts,
+		logger:          logger,
+	}
+}
+
+func (s *subscriber) On(operation ChangeOperation, handler ChangeHandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[operation] = handler
+}
+
+func (s *subscriber) Run(ctx context.Context) error {
+	lockName := "change_stream:" + s.name
+
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.lock.Release(releaseCtx, lockName, s.id); err != nil {
+			s.logger.Warn(releaseCtx, "failed to release subscriber lock", log.String("subscription", s.name), log.Error(err))
+		}
+	}()
+
+	for {
+		isLeader, err := s.lock.Acquire(ctx, lockName, s.id, s.lockTTL)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Error(ctx, "failed to acquire subscriber lock", log.String("subscription", s.name), log.Error(err))
+		}
+		if isLeader {
+			if err := s.lead(ctx, lockName); err != nil && ctx.Err() == nil {
+				s.logger.Error(ctx, "change stream stopped", log.String("subscription", s.name), log.Error(err))
+			}
+		}
+
+		timer := time.NewTimer(s.retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// lead watches the collection while renewing the lock, and stops watching as soon as the lock is lost.
+func (s *subscriber) lead(ctx context.Context, lockName string) error {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		ticker := time.NewTicker(s.lockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-watchCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			isLeader, err := s.lock.Acquire(watchCtx, lockName, s.id, s.lockTTL)
+			if err != nil || !isLeader {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	err := s.watch(watchCtx)
+	cancel()
+	<-renewed
+
+	return err
+}
+
+func (s *subscriber) watch(ctx context.Context) error {
+	s.mu.RLock()
+	operations := bson.A{}
+	for operation := range s.handlers {
+		operations = append(operations, operation)
+	}
+	s.mu.RUnlock()
+
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+
+	var token entity.ChangeStreamToken
+	err := s.mongoDBAdapter.FindOne(ctx, s.tokenCollection, &token, bson.D{{Key: "_id", Value: s.name}})
+	if err != nil && !errors.Is(err, adapter.ErrNoDocuments) {
+		return err
+	}
+	if len(token.Token) > 0 {
+		opts.SetResumeAfter(token.Token)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "operationType", Value: bson.D{{Key: "$in", Value: operations}}}}}},
+	}
+	stream, err := s.mongoDBAdapter.Watch(ctx, s.collection, pipeline, opts)
+	if err != nil {
+		var commandErr mongo.CommandError
+		if errors.As(err, &commandErr) && (commandErr.Code == changeStreamHistoryLost || commandErr.Code == changeStreamFatalError) {
+			// The changes since the token are gone; start again from now rather than failing forever.
+			s.logger.Warn(ctx, "resume token expired, changes were missed", log.String("subscription", s.name), log.Error(err))
+			_, err = s.mongoDBAdapter.DeleteOne(ctx, s.tokenCollection, bson.D{{Key: "_id", Value: s.name}})
+		}
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	s.logger.Info(ctx, "watching collection", log.String("subscription", s.name), log.String("collection", s.collection.Name()))
+
+	for stream.Next(ctx) {
+		if err := s.handle(ctx, stream.Current); err != nil {
+			return err
+		}
+
+		_, err := s.mongoDBAdapter.UpdateOne(ctx, s.tokenCollection,
+			bson.D{{Key: "_id", Value: s.name}},
+			bson.D{{Key: "$set", Value: bson.D{
+				{Key: "token", Value: stream.ResumeToken()},
+				{Key: "updated_at", Value: time.Now()},
+			}}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return stream.Err()
+}
+
+// handle dispatches a change until its handler succeeds or it had maxAttempts. A change that still fails is logged
+// and skipped, so that it does not hold back every change after it. It only returns an error when ctx is done.
+func (s *subscriber) handle(ctx context.Context, raw bson.Raw) error {
+	for attempt := 1; ; attempt++ {
+		err := s.dispatch(ctx, raw)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+
+		fields := []log.Field{
+			log.String("subscription", s.name),
+			log.String("documentKey", raw.Lookup("documentKey").String()),
+			log.Int("attempt", attempt),
+			log.Error(err),
+		}
+		if attempt >= s.maxAttempts {
+			s.logger.Error(ctx, "change skipped after its last failed attempt", fields...)
+			return nil
+		}
+		s.logger.Warn(ctx, "change handler failed, retrying", fields...)
+
+		timer := time.NewTimer(s.retryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *subscriber) dispatch(ctx context.Context, raw bson.Raw) error {
+	var document changeStreamDocument
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	handler, ok := s.handlers[document.OperationType]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	return handler(ctx, ChangeEvent{
+		Operation:     document.OperationType,
+		DocumentID:    document.DocumentKey.ID,
+		FullDocument:  document.FullDocument,
+		UpdatedFields: document.UpdateDescription.UpdatedFields,
+		RemovedFields: document.UpdateDescription.RemovedFields,
+		ClusterTime:   document.ClusterTime,
+	})
+}
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type SubscriberSuite struct {
+	suite.Suite
+	logger log.ILogger
+}
+
+func (s *SubscriberSuite) SetupSuite() {
+	var err error
+	s.logger, err = log.NewLoggerZap(&log.ZapConfig{})
+	s.Require().NoError(err)
+}
+
+func TestSubscriberSuite(t *testing.T) {
+	suite.Run(t, new(SubscriberSuite))
+}
+
+func (s *SubscriberSuite) TestTypedChangeHandler() {
+
+	s.Run("should decode the full document", func() {
+		document, err := bson.Marshal(bson.D{{Key: "name", Value: "John Doe"}})
+		s.Require().NoError(err)
+
+		var got *entity.User
+		handler := TypedChangeHandler(func(ctx context.Context, event ChangeEvent, user *entity.User) error {
+			got = user
+			return nil
+		})
+
+		s.NoError(handler(context.Background(), ChangeEvent{Operation: ChangeInsert, FullDocument: document}))
+		s.Require().NotNil(got)
+		s.Equal("John Doe", got.Name)
+	})
+
+	s.Run("should pass nil when the change has no full document", func() {
+		called := false
+		handler := TypedChangeHandler(func(ctx context.Context, event ChangeEvent, user *entity.User) error {
+			called = true
+			s.Nil(user)
+			return nil
+		})
+
+		s.NoError(handler(context.Background(), ChangeEvent{Operation: ChangeDelete}))
+		s.True(called)
+	})
+}
+
+func (s *SubscriberSuite) TestHandle() {
+	raw, err := bson.Marshal(bson.D{
+		{Key: "operationType", Value: ChangeInsert},
+		{Key: "documentKey", Value: bson.D{{Key: "_id", Value: "1"}}},
+	})
+	s.Require().NoError(err)
+
+	// failing returns a subscriber whose insert handler fails the given number of times, and the number of calls.
+	failing := func(failures int) (*subscriber, *int) {
+		calls := 0
+		return &subscriber{
+			handlers: map[ChangeOperation]ChangeHandlerFunc{
+				ChangeInsert: func(ctx context.Context, event ChangeEvent) error {
+					calls++
+					if calls <= failures {
+						return errors.New("handler failed")
+					}
+					return nil
+				},
+			},
+			retryInterval: time.Millisecond,
+			maxAttempts:   3,
+			logger:        s.logger,
+		}, &calls
+	}
+
+	s.Run("should hand a failed change to the handler again", func() {
+		subscriber, calls := failing(2)
+
+		s.NoError(subscriber.handle(context.Background(), raw))
+		s.Equal(3, *calls)
+	})
+
+	s.Run("should skip a change that fails every attempt", func() {
+		subscriber, calls := failing(10)
+
+		s.NoError(subscriber.handle(context.Background(), raw))
+		s.Equal(3, *calls)
+	})
+
+	s.Run("should stop without skipping the change when ctx is done", func() {
+		subscriber, calls := failing(10)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		s.Error(subscriber.handle(ctx, raw))
+		s.Equal(1, *calls)
+	})
+}
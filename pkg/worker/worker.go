@@ -0,0 +1,387 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+)
+
+var ErrWorkerClosed = errors.New("worker is shutting down")
+
+// HandlerFunc processes a single job. Returning an error fails the attempt; the job's retry
+// policy decides whether it runs again.
+type HandlerFunc func(ctx context.Context, job entity.Job) error
+
+type IWorker interface {
+	// Register binds a handler to a job type using the worker's default retry policy.
+	// Registering the same type twice replaces the handler.
+	Register(jobType string, handler HandlerFunc)
+	// RegisterWithPolicy binds a handler to a job type with its own retry policy.
+	RegisterWithPolicy(jobType string, handler HandlerFunc, policy RetryPolicy)
+	// Submit blocks until a slot in the pool is free, then runs the job in the background
+	// without going through the queue.
+	Submit(ctx context.Context, job entity.Job) error
+	// Run claims jobs of the registered types from the queue until ctx is done or the worker
+	// is shut down.
+	Run(ctx context.Context) error
+	// Shutdown stops accepting jobs and waits for in-flight jobs to finish. When ctx expires first,
+	// in-flight jobs are cancelled and ctx.Err() is returned.
+	Shutdown(ctx context.Context) error
+}
+
+type WorkerConfig struct {
+	// ID identifies this worker in job leases. Defaults to hostname-pid.
+	ID                string
+	Concurrency       int
+	PollInterval      time.Duration
+	HeartbeatInterval time.Duration
+	// RetryPolicy applies to job types registered without their own policy.
+	// Defaults to DefaultRetryPolicy.
+	RetryPolicy *RetryPolicy
+}
+
+type registration struct {
+	handler HandlerFunc
+	policy  RetryPolicy
+}
+
+const (
+	defaultPollInterval      = time.Second
+	defaultHeartbeatInterval = 10 * time.Second
+)
+
+type worker struct {
+	id                string
+	queue             adapter.IQueue
+	handlers          map[string]registration
+	retryPolicy       RetryPolicy
+	slots             chan struct{}
+	done              chan struct{}
+	closed            bool
+	mu                sync.RWMutex
+	wg                sync.WaitGroup
+	ctx               context.Context
+	cancel            context.CancelFunc
+	pollInterval      time.Duration
+	heartbeatInterval time.Duration
+	logger            log.ILogger
+}
+
+func NewWorker(workerConfig WorkerConfig, queue adapter.IQueue, logger log.ILogger) IWorker {
+	if workerConfig.ID == "" {
+		hostname, _ := os.Hostname()
+		workerConfig.ID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if workerConfig.Concurrency < 1 {
+		workerConfig.Concurrency = 1
+	}
+	if workerConfig.PollInterval <= 0 {
+		workerConfig.PollInterval = defaultPollInterval
+	}
+	if workerConfig.HeartbeatInterval <= 0 {
+		workerConfig.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	retryPolicy := DefaultRetryPolicy()
+	if workerConfig.RetryPolicy != nil {
+		retryPolicy = *workerConfig.RetryPolicy
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &worker{
+		id:                workerConfig.ID,
+		queue:             queue,
+		handlers:          map[string]registration{},
+		retryPolicy:       retryPolicy,
+		slots:             make(chan struct{}, workerConfig.Concurrency),
+		done:              make(chan struct{}),
+		ctx:               ctx,
+		cancel:            cancel,
+		pollInterval:      workerConfig.PollInterval,
+		heartbeatInterval: workerConfig.HeartbeatInterval,
+		logger:            logger,
+	}
+}
+
+func (w *worker) Register(jobType string, handler HandlerFunc) {
+	w.RegisterWithPolicy(jobType, handler, w.retryPolicy)
+}
+
+func (w *worker) RegisterWithPolicy(jobType string, handler HandlerFunc, policy RetryPolicy) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.handlers[jobType] = registration{handler, policy}
+}
+
+func (w *worker) Submit(ctx context.Context, job entity.Job) error {
+	registration, ok := w.registration(job.Type)
+	if !ok {
+		return handlerNotFoundError(job.Type)
+	}
+
+	if err := w.acquire(ctx); err != nil {
+		return err
+	}
+
+	go w.run(registration.handler, job)
+
+	return nil
+}
+
+func (w *worker) Run(ctx context.Context) error {
+	for {
+		if err := w.acquire(ctx); err != nil {
+			if errors.Is(err, ErrWorkerClosed) {
+				return nil
+			}
+			return err
+		}
+
+		job, err := w.queue.Claim(ctx, w.id, w.maxAttempts())
+		if err != nil {
+			w.releaseSlot()
+			if !errors.Is(err, adapter.ErrQueueEmpty) {
+				w.logger.Error(ctx, "failed to claim job", log.Error(err))
+			}
+			if err := w.wait(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		go w.process(job)
+	}
+}
+
+// acquire reserves a pool slot for one job. The caller must eventually call releaseSlot.
+func (w *worker) acquire(ctx context.Context) error {
+	select {
+	case w.slots <- struct{}{}:
+	case <-w.done:
+		return ErrWorkerClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		<-w.slots
+		return ErrWorkerClosed
+	}
+	w.wg.Add(1)
+
+	return nil
+}
+
+func (w *worker) releaseSlot() {
+	<-w.slots
+	w.wg.Done()
+}
+
+// wait sleeps for one poll interval, returning early when the worker or ctx stops.
+func (w *worker) wait(ctx context.Context) error {
+	timer := time.NewTimer(w.pollInterval)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *worker) registration(jobType string) (registration, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	registration, ok := w.handlers[jobType]
+	return registration, ok
+}
+
+// maxAttempts returns the maximum attempts of each registered job type.
+func (w *worker) maxAttempts() map[string]int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	maxAttempts := make(map[string]int, len(w.handlers))
+	for jobType, registration := range w.handlers {
+		maxAttempts[jobType] = registration.policy.MaxAttempts
+	}
+	return maxAttempts
+}
+
+func (w *worker) run(handler HandlerFunc, job entity.Job) {
+	defer w.releaseSlot()
+
+	if err := w.execute(w.ctx, handler, job); err != nil {
+		w.logger.Error(w.ctx, "job failed", jobFields(job, log.Error(err))...)
+		return
+	}
+
+	w.logger.Debug(w.ctx, "job completed", jobFields(job)...)
+}
+
+// process runs a claimed job while keeping its lease alive, then reports the outcome to the queue.
+func (w *worker) process(job *entity.Job) {
+	defer w.releaseSlot()
+
+	registration, ok := w.registration(job.Type)
+	if !ok {
+		registration = w.unregistered(job.Type)
+	}
+
+	ctx, cancel := context.WithCancel(w.ctx)
+	defer cancel()
+
+	// The handler gets its own copy because heartbeat updates the lease on job.
+	claimed := *job
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		w.heartbeat(ctx, cancel, job)
+	}()
+
+	err := w.execute(ctx, registration.handler, claimed)
+	cancel()
+	<-heartbeatDone
+
+	if err != nil {
+		w.fail(job, registration.policy, err)
+		return
+	}
+
+	if err := w.queue.Complete(w.ctx, job); err != nil {
+		w.logger.Error(w.ctx, "failed to mark job as completed", jobFields(*job, log.Error(err))...)
+		return
+	}
+
+	w.logger.Debug(w.ctx, "job completed", jobFields(*job)...)
+}
+
+// fail records the failed attempt and either schedules a retry or dead-letters the job.
+func (w *worker) fail(job *entity.Job, policy RetryPolicy, err error) {
+	jobErr := entity.JobError{
+		Attempt:  job.Attempts,
+		Message:  err.Error(),
+		FailedAt: time.Now(),
+	}
+	var appErr apperror.AppError
+	if errors.As(err, &appErr) {
+		jobErr.Code = string(appErr.Code)
+	}
+
+	if policy.ShouldRetry(job.Attempts, err) {
+		runAt := jobErr.FailedAt.Add(policy.Backoff(job.Attempts))
+		w.logger.Warn(w.ctx, "job failed, retrying", jobFields(*job, log.Error(err), log.String("runAt", runAt.Format(time.RFC3339)))...)
+		if err := w.queue.Retry(w.ctx, job, jobErr, runAt); err != nil {
+			w.logger.Error(w.ctx, "failed to schedule job retry", jobFields(*job, log.Error(err))...)
+		}
+		return
+	}
+
+	w.logger.Error(w.ctx, "job failed, moving to dead-letter collection", jobFields(*job, log.Error(err))...)
+	if err := w.queue.DeadLetter(w.ctx, job, jobErr); err != nil {
+		w.logger.Error(w.ctx, "failed to dead-letter job", jobFields(*job, log.Error(err))...)
+	}
+}
+
+// unregistered stands in for a handler that is missing, failing the job without retries.
+func (w *worker) unregistered(jobType string) registration {
+	return registration{
+		handler: func(context.Context, entity.Job) error {
+			return handlerNotFoundError(jobType)
+		},
+		policy: RetryPolicy{},
+	}
+}
+
+// heartbeat renews the job's lease until ctx is done. Losing the lease cancels the job,
+// because another worker may already be running it.
+func (w *worker) heartbeat(ctx context.Context, cancel context.CancelFunc, job *entity.Job) {
+	ticker := time.NewTicker(w.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := w.queue.Heartbeat(ctx, job)
+			if errors.Is(err, adapter.ErrLeaseLost) {
+				w.logger.Warn(ctx, "job lease lost, cancelling job", jobFields(*job)...)
+				cancel()
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				w.logger.Error(ctx, "failed to renew job lease", jobFields(*job, log.Error(err))...)
+			}
+		}
+	}
+}
+
+func (w *worker) execute(ctx context.Context, handler HandlerFunc, job entity.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+func (w *worker) Shutdown(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+	w.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		return ctx.Err()
+	}
+}
+
+func handlerNotFoundError(jobType string) error {
+	return apperror.NewError(
+		"Job handler not found",
+		fmt.Sprintf("no handler registered for job type %q", jobType),
+		apperror.NotFound,
+	)
+}
+
+func jobFields(job entity.Job, fields ...log.Field) []log.Field {
+	return append([]log.Field{
+		log.String("jobID", job.ID.Hex()),
+		log.String("jobType", job.Type),
+		log.Int("attempt", job.Attempts),
+	}, fields...)
+}
@@ -0,0 +1,272 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/wisesight/go-api-template/pkg/adapter"
+	"github.com/wisesight/go-api-template/pkg/apperror"
+	"github.com/wisesight/go-api-template/pkg/entity"
+	"github.com/wisesight/go-api-template/pkg/log"
+	"github.com/wisesight/go-api-template/pkg/worker"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryQueue is an in-memory adapter.IQueue that records how each job ended.
+type memoryQueue struct {
+	mu        sync.Mutex
+	pending   []*entity.Job
+	completed []primitive.ObjectID
+	retried   []primitive.ObjectID
+	dead      []primitive.ObjectID
+}
+
+func (q *memoryQueue) Enqueue(ctx context.Context, job *entity.Job) (*primitive.ObjectID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+	for _, pending := range q.pending {
+		if pending.ID == job.ID {
+			return nil, adapter.ErrJobExists
+		}
+	}
+	job.State = entity.JobStatePending
+	q.pending = append(q.pending, job)
+	return &job.ID, nil
+}
+
+func (q *memoryQueue) Claim(ctx context.Context, workerID string, maxAttempts map[string]int) (*entity.Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 {
+		return nil, adapter.ErrQueueEmpty
+	}
+	job := q.pending[0]
+	q.pending = q.pending[1:]
+	job.State = entity.JobStateRunning
+	job.LockedBy = workerID
+	job.Attempts++
+	return job, nil
+}
+
+func (q *memoryQueue) Heartbeat(ctx context.Context, job *entity.Job) error {
+	return nil
+}
+
+func (q *memoryQueue) Complete(ctx context.Context, job *entity.Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.completed = append(q.completed, job.ID)
+	return nil
+}
+
+func (q *memoryQueue) Retry(ctx context.Context, job *entity.Job, jobErr entity.JobError, runAt time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job.State = entity.JobStatePending
+	job.Errors = append(job.Errors, jobErr)
+	q.retried = append(q.retried, job.ID)
+	q.pending = append(q.pending, job)
+	return nil
+}
+
+func (q *memoryQueue) DeadLetter(ctx context.Context, job *entity.Job, jobErr entity.JobError) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job.State = entity.JobStateDead
+	job.Errors = append(job.Errors, jobErr)
+	q.dead = append(q.dead, job.ID)
+	return nil
+}
+
+func (q *memoryQueue) finished() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.completed) + len(q.dead)
+}
+
+type WorkerSuite struct {
+	suite.Suite
+	logger log.ILogger
+}
+
+func (s *WorkerSuite) SetupSuite() {
+	var err error
+	s.logger, err = log.NewLoggerZap(&log.ZapConfig{})
+	s.NoError(err)
+}
+
+func TestWorkerSuite(t *testing.T) {
+	suite.Run(t, new(WorkerSuite))
+}
+
+func (s *WorkerSuite) TestSubmit() {
+
+	s.Run("should return not found when job type is not registered", func() {
+		w := worker.NewWorker(worker.WorkerConfig{Concurrency: 1}, nil, s.logger)
+
+		err := w.Submit(context.Background(), entity.Job{Type: "unknown"})
+
+		var appErr apperror.AppError
+		s.True(errors.As(err, &appErr))
+		s.Equal(apperror.NotFound, appErr.Code)
+	})
+
+	s.Run("should never run more jobs than the concurrency limit", func() {
+		w := worker.NewWorker(worker.WorkerConfig{Concurrency: 2}, nil, s.logger)
+
+		var running, peak int32
+		w.Register("sleep", func(ctx context.Context, job entity.Job) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+
+		for i := 0; i < 10; i++ {
+			s.NoError(w.Submit(context.Background(), entity.Job{Type: "sleep"}))
+		}
+		s.NoError(w.Shutdown(context.Background()))
+
+		s.LessOrEqual(peak, int32(2))
+	})
+
+	s.Run("should return error when worker is shut down", func() {
+		w := worker.NewWorker(worker.WorkerConfig{Concurrency: 1}, nil, s.logger)
+		w.Register("noop", func(ctx context.Context, job entity.Job) error { return nil })
+
+		s.NoError(w.Shutdown(context.Background()))
+
+		s.ErrorIs(w.Submit(context.Background(), entity.Job{Type: "noop"}), worker.ErrWorkerClosed)
+	})
+}
+
+func (s *WorkerSuite) TestShutdown() {
+
+	s.Run("should wait for in-flight jobs to finish", func() {
+		w := worker.NewWorker(worker.WorkerConfig{Concurrency: 1}, nil, s.logger)
+
+		var finished int32
+		w.Register("slow", func(ctx context.Context, job entity.Job) error {
+			time.Sleep(20 * time.Millisecond)
+			atomic.StoreInt32(&finished, 1)
+			return nil
+		})
+
+		s.NoError(w.Submit(context.Background(), entity.Job{Type: "slow"}))
+		s.NoError(w.Shutdown(context.Background()))
+
+		s.Equal(int32(1), atomic.LoadInt32(&finished))
+	})
+
+	s.Run("should cancel in-flight jobs when the deadline passes", func() {
+		w := worker.NewWorker(worker.WorkerConfig{Concurrency: 1}, nil, s.logger)
+
+		cancelled := make(chan struct{})
+		w.Register("block", func(ctx context.Context, job entity.Job) error {
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		})
+
+		s.NoError(w.Submit(context.Background(), entity.Job{Type: "block"}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		s.ErrorIs(w.Shutdown(ctx), context.DeadlineExceeded)
+		<-cancelled
+	})
+}
+
+func (s *WorkerSuite) TestRun() {
+
+	s.Run("should complete successful jobs and dead-letter the others after retrying", func() {
+		queue := &memoryQueue{}
+		w := worker.NewWorker(worker.WorkerConfig{Concurrency: 2, PollInterval: time.Millisecond}, queue, s.logger)
+		w.Register("ok", func(ctx context.Context, job entity.Job) error { return nil })
+		w.RegisterWithPolicy("fail", func(ctx context.Context, job entity.Job) error {
+			return errors.New("boom")
+		}, worker.RetryPolicy{MaxAttempts: 3})
+
+		okJob := &entity.Job{Type: "ok"}
+		failJob := &entity.Job{Type: "fail"}
+		queue.Enqueue(context.Background(), okJob)
+		queue.Enqueue(context.Background(), failJob)
+
+		go w.Run(context.Background())
+		s.Eventually(func() bool { return queue.finished() == 2 }, time.Second, time.Millisecond)
+		s.NoError(w.Shutdown(context.Background()))
+
+		s.Equal([]primitive.ObjectID{okJob.ID}, queue.completed)
+		s.Equal([]primitive.ObjectID{failJob.ID, failJob.ID}, queue.retried)
+		s.Equal([]primitive.ObjectID{failJob.ID}, queue.dead)
+		s.Len(failJob.Errors, 3)
+	})
+
+	s.Run("should dead-letter non-retryable errors on the first attempt", func() {
+		queue := &memoryQueue{}
+		w := worker.NewWorker(worker.WorkerConfig{Concurrency: 1, PollInterval: time.Millisecond}, queue, s.logger)
+		w.Register("missing", func(ctx context.Context, job entity.Job) error {
+			return apperror.NewError("User not found", "User not found", apperror.NotFound)
+		})
+
+		job := &entity.Job{Type: "missing"}
+		queue.Enqueue(context.Background(), job)
+
+		go w.Run(context.Background())
+		s.Eventually(func() bool { return queue.finished() == 1 }, time.Second, time.Millisecond)
+		s.NoError(w.Shutdown(context.Background()))
+
+		s.Empty(queue.retried)
+		s.Equal(string(apperror.NotFound), job.Errors[0].Code)
+	})
+}
+
+func (s *WorkerSuite) TestRetryPolicy() {
+
+	s.Run("should grow the backoff exponentially up to the maximum", func() {
+		policy := worker.RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+
+		s.Equal(time.Second, policy.Backoff(1))
+		s.Equal(2*time.Second, policy.Backoff(2))
+		s.Equal(4*time.Second, policy.Backoff(3))
+		s.Equal(5*time.Second, policy.Backoff(4))
+	})
+
+	s.Run("should keep jittered backoff within the jitter fraction", func() {
+		policy := worker.RetryPolicy{InitialBackoff: time.Second, Multiplier: 2, Jitter: 0.5}
+
+		for i := 0; i < 100; i++ {
+			backoff := policy.Backoff(2)
+			s.GreaterOrEqual(backoff, time.Second)
+			s.LessOrEqual(backoff, 2*time.Second)
+		}
+	})
+
+	s.Run("should stop retrying after max attempts", func() {
+		policy := worker.RetryPolicy{MaxAttempts: 2}
+
+		s.True(policy.ShouldRetry(1, errors.New("boom")))
+		s.False(policy.ShouldRetry(2, errors.New("boom")))
+	})
+}